@@ -0,0 +1,171 @@
+package xlogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+//AdminAuthFunc returns true if the request is allowed to use the admin handler
+type AdminAuthFunc func(r *http.Request) bool
+
+//adminStatus body returned by the admin handler
+type adminStatus struct {
+	LogFilePath string  `json:"logFilePath"`
+	Config      *Config `json:"config"`
+}
+
+//adminStreams body of PUT .../streams
+type adminStreams struct {
+	Enable bool `json:"enable"`
+	//All enables/disables all streams, Streams is ignored
+	All     bool  `json:"all"`
+	Streams []int `json:"streams"`
+}
+
+//maxAdminBody max size of a request body
+const maxAdminBody = 1 << 20
+
+//AdminHandler returns a http.Handler to view and change the log settings at runtime.
+//auth is called for every request, nil allows all requests.
+//
+//	GET  /          current settings and log file path
+//	PUT  /          applies a json Config (see ApplyConfig), only the fields that are set change.
+//	                The body of GET / is accepted too, its logFilePath is ignored.
+//	                The log file is reopened when a file setting changed, the response has the new path
+//	GET  /streams   enabled streams
+//	PUT  /streams   {"enable":true,"streams":[1,2]} or {"enable":false,"all":true}
+//	GET  /metrics   counters in the Prometheus text format, see MetricsHandler()
+//
+//Paths are matched on their suffix so the handler can be mounted on any prefix
+func AdminHandler(auth AdminAuthFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil && !auth(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		if strings.HasSuffix(path, "/streams") {
+			serveAdminStreams(w, r)
+		} else if strings.HasSuffix(path, "/metrics") {
+			MetricsHandler().ServeHTTP(w, r)
+		} else {
+			serveAdminConfig(w, r)
+		}
+	})
+}
+
+func serveAdminConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var body json.RawMessage
+		if !readAdminBody(w, r, &body) {
+			return
+		}
+		config, err := parseAdminConfig(body)
+		if err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := getFileSettings()
+		err = ApplyConfig(config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Warnf("Admin: log settings changed by %v", r.RemoteAddr)
+		reopenOnFileChange(previous, "Admin")
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeAdminJSON(w, adminStatus{LogFilePath: GetLogFilePath(), Config: CurrentConfig()})
+}
+
+func serveAdminStreams(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		body := &adminStreams{}
+		if !readAdminBody(w, r, body) {
+			return
+		}
+		streams := make([]byte, len(body.Streams))
+		for i, stream := range body.Streams {
+			if stream < 0 || stream > 63 {
+				http.Error(w, "stream out of range (0,63)", http.StatusBadRequest)
+				return
+			}
+			streams[i] = byte(stream)
+		}
+		if body.All {
+			EnableAllStreams(body.Enable)
+		} else {
+			EnableStreams(body.Enable, streams...)
+		}
+		Warnf("Admin: streams changed by %v", r.RemoteAddr)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeAdminJSON(w, CurrentConfig().Streams)
+}
+
+//parseAdminConfig reads a bare Config or the {"logFilePath","config"} body returned by GET
+func parseAdminConfig(body []byte) (*Config, error) {
+	var keys map[string]json.RawMessage
+	err := json.Unmarshal(body, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isStatus := keys["config"]; isStatus {
+		status := &adminStatus{}
+		err = decodeAdminJSON(bytes.NewReader(body), status)
+		if err != nil {
+			return nil, err
+		}
+		if status.Config == nil {
+			return &Config{}, nil
+		}
+		return status.Config, nil
+	}
+
+	config := &Config{}
+	err = decodeAdminJSON(bytes.NewReader(body), config)
+	return config, err
+}
+
+func readAdminBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := decodeAdminJSON(http.MaxBytesReader(w, r.Body, maxAdminBody), v)
+	if err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+//decodeAdminJSON decodes json, unknown fields are an error
+func decodeAdminJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(v)
+	if err != nil {
+		Warn("Admin: failed to write response.", err)
+	}
+}
@@ -0,0 +1,35 @@
+package xlogging
+
+//captureFn gets entries instead of the log file if set
+var captureFn func(e Entry)
+
+//Capture sends every entry that would be written to fn instead of the log file and terminal. Used by tests (see xlogtest).
+//Caller details are always set while capturing. Returns a function that restores the previous output
+func Capture(fn func(e Entry)) (restore func()) {
+	settingsMu.Lock()
+	previous := captureFn
+	captureFn = fn
+	settingsMu.Unlock()
+
+	return func() {
+		settingsMu.Lock()
+		captureFn = previous
+		settingsMu.Unlock()
+	}
+}
+
+//captureEntry returns true if the entry was captured and must not be written
+func captureEntry(e *entry) bool {
+	settingsMu.RLock()
+	fn := captureFn
+	settingsMu.RUnlock()
+
+	if fn == nil {
+		return false
+	}
+
+	captured := e.Entry
+	captured.Fields = append([]Field(nil), e.Fields...)
+	fn(captured)
+	return true
+}
@@ -0,0 +1,279 @@
+//xlogq queries xlogging log files.
+//
+//	xlogq [query] [flags] [folder or file...]
+//
+//Reads all log files (rotated and .gz) of the folders, "logs" by default, oldest first.
+//Lines are read in the default layout. Logs written with a pattern, level names or prefixes
+//are read with -config, the json config file of the app (its pattern, levelNames and prefixes).
+//
+//	xlogq -level warn,error -since 2h -grep timeout logs
+//	xlogq -stream 3 -file server.go -json logs/Log_17_10_2026.log
+//	xlogq -config app/xlogging.json -level error logs
+//
+//follow prints new records of the active log file of a folder as they are written, across rotations:
+//
+//	xlogq follow -level error logs
+//
+//merge prints the records of several folders in time order, each line tagged with its origin.
+//A path can name its tag and the zone of its dates: [tag=]path[@zone]
+//
+//	xlogq merge host1/logs host2/logs@UTC db=backup/db-logs
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasdeepgrewal/xlogging/xlogread"
+)
+
+const defaultFolder = "logs"
+
+//options shared by all commands
+type options struct {
+	filter   xlogread.Filter
+	loc      *time.Location
+	format   *xlogread.Format
+	jsonOut  bool
+	paths    []string
+	encoder  *json.Encoder
+	showFile bool
+}
+
+func main() {
+	args := os.Args[1:]
+	command := "query"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if _, ok := commands[args[0]]; ok {
+			command = args[0]
+			args = args[1:]
+		}
+	}
+
+	err := commands[command](args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xlogq:", err)
+		os.Exit(1)
+	}
+}
+
+var commands = map[string]func(args []string) error{
+	"query":  runQuery,
+	"follow": runFollow,
+	"merge":  runMerge,
+}
+
+//parseOptions parses the filter and output flags shared by all commands
+func parseOptions(name string, args []string, flags *flag.FlagSet) (*options, error) {
+	levels := flags.String("level", "", "comma separated levels: info,warn,error,nofmt")
+	since := flags.String("since", "", "records at or after this time (RFC3339, \"2006/01/02 15:04:05\" or a duration like 2h)")
+	until := flags.String("until", "", "records at or before this time")
+	streams := flags.String("stream", "", "comma separated InfoS streams")
+	file := flags.String("file", "", "caller file name (glob or sub string)")
+	grep := flags.String("grep", "", "regular expression matched against the message and stack")
+	utc := flags.Bool("utc", false, "dates in the files are UTC (logs written with useUTC)")
+	jsonOut := flags.Bool("json", false, "write records as json lines")
+	showFile := flags.Bool("source", false, "write the source file before each record")
+	config := flags.String("config", "", "xlogging json config of the app, its pattern, levelNames and prefixes are used to read the lines")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: xlogq %v [flags] [folder or file...]\n", name)
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &options{loc: time.Local, jsonOut: *jsonOut, showFile: *showFile}
+	if *utc {
+		o.loc = time.UTC
+	}
+	if *config != "" {
+		o.format, err = xlogread.ReadFormat(*config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o.filter.Levels = xlogread.ParseLevels(*levels)
+	if *since != "" {
+		o.filter.Since, err = xlogread.ParseTime(*since, o.loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if *until != "" {
+		o.filter.Until, err = xlogread.ParseTime(*until, o.loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range strings.Split(*streams, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		stream, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad stream %q", s)
+		}
+		o.filter.Streams = append(o.filter.Streams, stream)
+	}
+	o.filter.File = *file
+	if *grep != "" {
+		o.filter.Message, err = regexp.Compile(*grep)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o.paths = flags.Args()
+	if len(o.paths) == 0 {
+		o.paths = []string{defaultFolder}
+	}
+
+	o.encoder = json.NewEncoder(os.Stdout)
+	return o, nil
+}
+
+//write prints a record as text or json. tag is the origin of merged records
+func (o *options) write(r *xlogread.Record, tag string) error {
+	if o.jsonOut {
+		if tag != "" {
+			return o.encoder.Encode(struct {
+				Tag string `json:"tag"`
+				*xlogread.Record
+			}{tag, r})
+		}
+		return o.encoder.Encode(r)
+	}
+
+	if o.showFile {
+		fmt.Println("==> " + r.Source)
+	}
+	for _, line := range r.Lines {
+		if tag != "" {
+			line = "[" + tag + "] " + line
+		}
+		_, err := fmt.Println(line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runQuery(args []string) error {
+	o, err := parseOptions("query", args, flag.NewFlagSet("query", flag.ExitOnError))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range o.paths {
+		files, err := xlogread.ListFiles(path)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			var errWrite error
+			err = xlogread.ReadFile(file, o.loc, o.format, &o.filter, func(r *xlogread.Record) bool {
+				errWrite = o.write(r, "")
+				return errWrite == nil
+			})
+			if err != nil {
+				return err
+			}
+			if errWrite != nil {
+				return errWrite
+			}
+		}
+	}
+
+	return nil
+}
+
+func runFollow(args []string) error {
+	flags := flag.NewFlagSet("follow", flag.ExitOnError)
+	fromStart := flags.Bool("all", false, "print the active file from the start")
+	interval := flags.Duration("interval", 0, "how often the file is checked (default 250ms)")
+	o, err := parseOptions("follow", args, flags)
+	if err != nil {
+		return err
+	}
+	if len(o.paths) != 1 {
+		return fmt.Errorf("follow reads one folder or file")
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	opts := xlogread.FollowOptions{Loc: o.loc, Format: o.format, Filter: &o.filter, Interval: *interval, FromStart: *fromStart, Stop: stop}
+	var errWrite error
+	err = xlogread.Follow(o.paths[0], opts, func(r *xlogread.Record) bool {
+		errWrite = o.write(r, "")
+		return errWrite == nil
+	})
+	if err != nil {
+		return err
+	}
+	return errWrite
+}
+
+func runMerge(args []string) error {
+	o, err := parseOptions("merge", args, flag.NewFlagSet("merge", flag.ExitOnError))
+	if err != nil {
+		return err
+	}
+
+	sources := make([]xlogread.MergeSource, len(o.paths))
+	for i, path := range o.paths {
+		sources[i], err = parseMergeSource(path, o.loc)
+		if err != nil {
+			return err
+		}
+		sources[i].Format = o.format
+	}
+
+	var errWrite error
+	err = xlogread.Merge(sources, &o.filter, func(r *xlogread.Record, tag string) bool {
+		errWrite = o.write(r, tag)
+		return errWrite == nil
+	})
+	if err != nil {
+		return err
+	}
+	return errWrite
+}
+
+//parseMergeSource parses [tag=]path[@zone]
+func parseMergeSource(arg string, loc *time.Location) (xlogread.MergeSource, error) {
+	source := xlogread.MergeSource{Path: arg, Loc: loc}
+
+	if i := strings.Index(source.Path, "="); i > 0 {
+		source.Tag, source.Path = source.Path[:i], source.Path[i+1:]
+	}
+
+	if i := strings.LastIndex(source.Path, "@"); i > 0 {
+		zone, err := time.LoadLocation(source.Path[i+1:])
+		if err != nil {
+			return source, fmt.Errorf("bad zone in %q: %v", arg, err)
+		}
+		source.Loc, source.Path = zone, source.Path[:i]
+	}
+
+	return source, nil
+}
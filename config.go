@@ -0,0 +1,447 @@
+package xlogging
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//Config logger settings that can be read from a json file with LoadConfig().
+//Empty values keep the current setting.
+//The config at the path in $XLOGGING_CONFIG is loaded before the log file is opened.
+//File settings changed later are used for the next log file, see ReopenLogFile()
+type Config struct {
+	//Levels enabled log types: "info", "warn", "error". [] turns off all logs
+	Levels []string `json:"levels"`
+	//Streams enabled InfoS streams (0-63). All other streams are disabled. [] disables all streams
+	Streams []int `json:"streams"`
+	//Styles styles for each log type, keyed by "info", "warn" or "error".
+	//Style names: "longFileName", "shortFileName", "printStack", "logToTerminal"
+	Styles map[string][]string `json:"styles"`
+
+	//Pattern line layout, see SetPattern(). "" restores the default layout
+	Pattern *string `json:"pattern"`
+	//LevelNames names written by %level, keyed by "info", "warn" or "error"
+	LevelNames map[string]string `json:"levelNames"`
+	//Prefixes prefixes written for each log type, keyed by "info", "warn" or "error"
+	Prefixes map[string]string `json:"prefixes"`
+
+	//Redact masks secrets in messages and fields, see SetRedaction(). {} turns it off
+	Redact *RedactConfig `json:"redact"`
+
+	//SplitNewRun create a new file everytime the app launches
+	SplitNewRun *bool `json:"splitNewRun"`
+	//SplitSizeMB size in MB after which a new log file is created. 0 to ignore
+	SplitSizeMB *int64 `json:"splitSizeMB"`
+	//SplitAgeSeconds split file if it is older than this seconds. 0 to ignore
+	SplitAgeSeconds *int64 `json:"splitAgeSeconds"`
+
+	//Folder log folder, absolute or relative to FolderBase, see SetLogFolder()
+	Folder string `json:"folder"`
+	//FolderBase what a relative Folder is resolved against: "workingDir", "executable" or "stateHome"
+	FolderBase string `json:"folderBase"`
+
+	//Rotation how old files are renamed: "counter", "timestamp" or "shift", see SetRotationScheme()
+	Rotation string `json:"rotation"`
+
+	//FileNamePattern name of log files, see SetFileNamePattern()
+	FileNamePattern string `json:"fileNamePattern"`
+	//FileBaseName value of {base} in FileNamePattern
+	FileBaseName string `json:"fileBaseName"`
+	//AppName value of {app} in FileNamePattern
+	AppName string `json:"appName"`
+	//CurrentLink name of a symlink in the log folder to the attached file, see SetCurrentLink(). "" turns it off
+	CurrentLink *string `json:"currentLink"`
+	//MaxFiles old log files are deleted when there are more than this. 0 keeps all files
+	MaxFiles *int `json:"maxFiles"`
+	//Sync when the log file is committed to disk: never, entry, warnerror or interval. See SetSyncPolicy()
+	Sync string `json:"sync"`
+	//SyncIntervalMs interval of the "interval" sync policy
+	SyncIntervalMs *int64 `json:"syncIntervalMs"`
+
+	//DirMode and FileMode octal modes of created log folders and files ("0750"), see SetFileModes()
+	DirMode  string `json:"dirMode"`
+	FileMode string `json:"fileMode"`
+	//Owner and Group user and group (name or id) of created log folders and files
+	Owner string `json:"owner"`
+	Group string `json:"group"`
+}
+
+//styleNames style names used in Config.Styles
+var styleNames = map[string]uint64{
+	"longFileName":  stLongFileName,
+	"shortFileName": stShortFileName,
+	"printStack":    stPrintStack,
+	"logToTerminal": stLogToTerminal,
+}
+
+//LoadConfig reads a json config file and applies it
+func LoadConfig(path string) error {
+	config, err := ReadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	return ApplyConfig(config)
+}
+
+//ReadConfig reads a json config file without applying it
+func ReadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, stdError{"Config " + path + ": " + err.Error()}
+	}
+
+	return config, nil
+}
+
+//ApplyConfig applies the settings in config.
+//All settings are changed together. Nothing is changed if config has an invalid value
+func ApplyConfig(config *Config) error {
+	var err error
+	var levelsNew uint64
+	if config.Levels != nil {
+		for _, name := range config.Levels {
+			logType, ok := parseLevel(name)
+			if !ok {
+				return stdError{"Config: Unknown level '" + name + "'"}
+			}
+			levelsNew |= logType
+		}
+	}
+
+	var streamsNew uint64
+	for _, stream := range config.Streams {
+		if stream < 0 || stream > 63 {
+			return stdError{"Config: Stream " + strconv.Itoa(stream) + " out of range (0,63)"}
+		}
+		streamsNew |= 1 << uint(stream)
+	}
+
+	stylesNew := make(map[uint64]uint64, len(config.Styles))
+	for levelName, names := range config.Styles {
+		logType, ok := parseLevel(levelName)
+		if !ok {
+			return stdError{"Config: Unknown level '" + levelName + "'"}
+		}
+		var style uint64
+		for _, name := range names {
+			flag, ok := styleNames[name]
+			if !ok {
+				return stdError{"Config: Unknown style '" + name + "'"}
+			}
+			style |= flag
+		}
+		stylesNew[logType] = style
+	}
+
+	var patternNew []layoutToken
+	if config.Pattern != nil {
+		patternNew, err = parsePattern(*config.Pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	levelNamesNew, err := parseLevelMap(config.LevelNames)
+	if err != nil {
+		return err
+	}
+
+	prefixesNew, err := parseLevelMap(config.Prefixes)
+	if err != nil {
+		return err
+	}
+
+	var redactionNew *redactor
+	if config.Redact != nil && !isRedactConfigEmpty(config.Redact) {
+		redactionNew, err = newRedactor(config.Redact)
+		if err != nil {
+			return err
+		}
+	}
+
+	if (config.SplitSizeMB != nil && *config.SplitSizeMB < 0) || (config.SplitAgeSeconds != nil && *config.SplitAgeSeconds < 0) {
+		return stdError{"Config: Split rules can not be negative"}
+	}
+	if config.CurrentLink != nil {
+		err = validateCurrentLinkName(*config.CurrentLink)
+		if err != nil {
+			return err
+		}
+	}
+	folderBaseNew, ok := folderBaseNames[config.FolderBase]
+	if config.FolderBase != "" && !ok {
+		return stdError{"Config: Unknown folder base '" + config.FolderBase + "'"}
+	}
+	rotationNew, ok := rotationSchemeNames[config.Rotation]
+	if config.Rotation != "" && !ok {
+		return stdError{"Config: Unknown rotation '" + config.Rotation + "'"}
+	}
+	if config.MaxFiles != nil && *config.MaxFiles < 0 {
+		return stdError{"Config: maxFiles can not be negative"}
+	}
+	syncPolicyNew, ok := syncPolicyNames[config.Sync]
+	if config.Sync != "" && !ok {
+		return stdError{"Config: Unknown sync policy '" + config.Sync + "'"}
+	}
+	if config.SyncIntervalMs != nil && *config.SyncIntervalMs <= 0 {
+		return stdError{"Config: syncIntervalMs must be positive"}
+	}
+	dirModeNew, err := parseFileMode(config.DirMode)
+	if err != nil {
+		return err
+	}
+	fileModeNew, err := parseFileMode(config.FileMode)
+	if err != nil {
+		return err
+	}
+	ownerNew, err := lookupID(config.Owner, false)
+	if err != nil {
+		return err
+	}
+	groupNew, err := lookupID(config.Group, true)
+	if err != nil {
+		return err
+	}
+
+	settingsMu.RLock()
+	namePattern, baseName, app := fileNamePattern, fileBaseName, appName
+	settingsMu.RUnlock()
+	if config.FileNamePattern != "" {
+		namePattern = config.FileNamePattern
+	}
+	if config.FileBaseName != "" {
+		baseName = config.FileBaseName
+	}
+	if config.AppName != "" {
+		app = config.AppName
+	}
+	fileNameRegexpNew, err := compileFileNameRegexp(namePattern, baseName, app, false)
+	if err != nil {
+		return err
+	}
+	settingsMu.RLock()
+	ownFileNameRegexpNew, err := compileFileNameRegexp(namePattern, baseName, app, true)
+	settingsMu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	//Everything is valid, apply
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if config.Levels != nil {
+		loggingLevel = levelsNew
+	}
+	if config.Streams != nil {
+		enabledStreams = streamsNew
+	}
+	for logType, style := range stylesNew {
+		setStyle(logType, style)
+	}
+
+	if config.Pattern != nil {
+		setPattern(*config.Pattern, patternNew)
+	}
+	for logType, name := range levelNamesNew {
+		levelNames[logType] = name
+	}
+	for logType, prefix := range prefixesNew {
+		levelPrefixes[logType] = prefix
+	}
+
+	if config.Redact != nil {
+		redaction = redactionNew
+	}
+
+	if config.SplitNewRun != nil {
+		splitRuleNewRun = *config.SplitNewRun
+	}
+	if config.SplitSizeMB != nil {
+		splitRuleSize = *config.SplitSizeMB
+	}
+	if config.SplitAgeSeconds != nil {
+		splitRuleAge = *config.SplitAgeSeconds
+	}
+
+	if config.Folder != "" {
+		logFolder = config.Folder
+	}
+	if config.FolderBase != "" {
+		logFolderBase = folderBaseNew
+	}
+
+	if config.Rotation != "" {
+		rotationScheme = rotationNew
+	}
+
+	fileNamePattern, fileBaseName, appName = namePattern, baseName, app
+	fileNameRegexp = fileNameRegexpNew
+	ownFileNameRegexp = ownFileNameRegexpNew
+	fileNameSortable = isSortableFileNamePattern(namePattern)
+	if config.CurrentLink != nil {
+		currentLinkName = *config.CurrentLink
+	}
+	if config.MaxFiles != nil {
+		maxLogFiles = *config.MaxFiles
+	}
+
+	if config.Sync != "" {
+		syncPolicy = syncPolicyNew
+	}
+	if config.SyncIntervalMs != nil {
+		syncInterval = time.Duration(*config.SyncIntervalMs) * time.Millisecond
+	}
+
+	if config.DirMode != "" {
+		dirMode = dirModeNew
+	}
+	if config.FileMode != "" {
+		fileMode = fileModeNew
+	}
+	if config.Owner != "" {
+		fileOwner = ownerNew
+	}
+	if config.Group != "" {
+		fileGroup = groupNew
+	}
+
+	return nil
+}
+
+func isRedactConfigEmpty(config *RedactConfig) bool {
+	return len(config.Builtins) == 0 && len(config.Patterns) == 0 && len(config.Fields) == 0
+}
+
+//setStyle settingsMu must be held
+func setStyle(logType, style uint64) {
+	switch logType {
+	case logInfo:
+		styleInfo = style
+	case logWarn:
+		styleWarn = style
+	case logError:
+		styleError = style
+	}
+}
+
+//parseLevelMap converts a map keyed by level name to one keyed by log type
+func parseLevelMap(values map[string]string) (map[uint64]string, error) {
+	result := make(map[uint64]string, len(values))
+	for name, value := range values {
+		logType, ok := parseLevel(name)
+		if !ok {
+			return nil, stdError{"Config: Unknown level '" + name + "'"}
+		}
+		result[logType] = value
+	}
+
+	return result, nil
+}
+
+//CurrentConfig returns the settings currently in use
+func CurrentConfig() *Config {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	config := &Config{
+		Levels:     []string{},
+		Streams:    []int{},
+		Styles:     make(map[string][]string, 3),
+		LevelNames: make(map[string]string, len(levelNames)),
+		Prefixes:   make(map[string]string, len(levelPrefixes)),
+	}
+
+	for _, logType := range []uint64{logInfo, logWarn, logError} {
+		levelName := getConfigLevelName(logType)
+		if checkFlag(loggingLevel, logType) {
+			config.Levels = append(config.Levels, levelName)
+		}
+
+		style := []string{}
+		for name, flag := range styleNames {
+			if checkFlag(getStyle(logType), flag) {
+				style = append(style, name)
+			}
+		}
+		sort.Strings(style)
+		config.Styles[levelName] = style
+
+		config.LevelNames[levelName] = getLevelName(logType)
+		config.Prefixes[levelName] = getLevelPrefix(logType)
+	}
+
+	for i := byte(0); i < 64; i++ {
+		if checkBit(enabledStreams, i) {
+			config.Streams = append(config.Streams, int(i))
+		}
+	}
+
+	pattern := linePatternText
+	config.Pattern = &pattern
+
+	splitNewRun, splitSize, splitAge := splitRuleNewRun, splitRuleSize, splitRuleAge
+	config.SplitNewRun = &splitNewRun
+	config.SplitSizeMB = &splitSize
+	config.SplitAgeSeconds = &splitAge
+
+	maxFiles, currentLink := maxLogFiles, currentLinkName
+	config.CurrentLink = &currentLink
+	config.Folder = logFolder
+	for name, base := range folderBaseNames {
+		if base == logFolderBase {
+			config.FolderBase = name
+		}
+	}
+	for name, scheme := range rotationSchemeNames {
+		if scheme == rotationScheme {
+			config.Rotation = name
+		}
+	}
+	config.FileNamePattern = fileNamePattern
+	config.FileBaseName = fileBaseName
+	config.AppName = appName
+	config.MaxFiles = &maxFiles
+
+	for name, policy := range syncPolicyNames {
+		if policy == syncPolicy {
+			config.Sync = name
+		}
+	}
+	syncMs := int64(syncInterval / time.Millisecond)
+	config.SyncIntervalMs = &syncMs
+
+	config.DirMode = formatFileMode(dirMode)
+	config.FileMode = formatFileMode(fileMode)
+	if fileOwner != -1 {
+		config.Owner = strconv.Itoa(fileOwner)
+	}
+	if fileGroup != -1 {
+		config.Group = strconv.Itoa(fileGroup)
+	}
+
+	return config
+}
+
+//getConfigLevelName returns the name of a log type used as a Config key
+func getConfigLevelName(logType uint64) string {
+	switch logType {
+	case logInfo:
+		return "info"
+	case logWarn:
+		return "warn"
+	case logError:
+		return "error"
+	}
+	return ""
+}
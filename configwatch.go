@@ -0,0 +1,141 @@
+package xlogging
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+//defaultWatchInterval used by WatchConfig when interval is 0
+const defaultWatchInterval = time.Second * 5
+
+//WatchConfig loads the json config at path and reloads it when the file changes (checked every interval) or the process receives SIGHUP.
+//A negative interval only reloads on SIGHUP. There is no SIGHUP on js.
+//Invalid changes are logged with Warn and ignored, the previous settings stay active.
+//The log file is reopened when a file setting changed (split rules, folder, file name, rotation, current link, modes, owner)
+//Returns a function that stops watching
+func WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	previous := getFileSettings()
+	err = LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	reopenOnFileChange(previous, "Config "+path)
+
+	if interval == 0 {
+		interval = defaultWatchInterval
+	}
+
+	lastModTime, lastSize := getConfigFileStamp(path)
+
+	hup := make(chan os.Signal, 1)
+	notifyHangup(hup)
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				lastModTime, lastSize = getConfigFileStamp(path)
+				reloadConfig(path)
+			case <-tick:
+				modTime, size := getConfigFileStamp(path)
+				if modTime.Equal(lastModTime) && size == lastSize {
+					continue
+				}
+				lastModTime, lastSize = modTime, size
+				reloadConfig(path)
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			if ticker != nil {
+				ticker.Stop()
+			}
+			close(done)
+		})
+	}
+
+	return stop, nil
+}
+
+//fileSettings settings used when the log file is attached
+type fileSettings struct {
+	splitSize, splitAge    int64
+	folder                 string
+	folderBase             FolderBase
+	rotation               RotationScheme
+	namePattern, base, app string
+	currentLink            string
+	dirMode, fileMode      os.FileMode
+	owner, group           int
+}
+
+func getFileSettings() fileSettings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return fileSettings{
+		splitSize:   splitRuleSize,
+		splitAge:    splitRuleAge,
+		folder:      logFolder,
+		folderBase:  logFolderBase,
+		rotation:    rotationScheme,
+		namePattern: fileNamePattern,
+		base:        fileBaseName,
+		app:         appName,
+		currentLink: currentLinkName,
+		dirMode:     dirMode,
+		fileMode:    fileMode,
+		owner:       fileOwner,
+		group:       fileGroup,
+	}
+}
+
+func reloadConfig(path string) {
+	previous := getFileSettings()
+	err := LoadConfig(path)
+	if err != nil {
+		Warn("Config reload failed, keeping previous settings.", err)
+		return
+	}
+
+	NoFmt("LOGGER CONFIG: Reloaded " + path)
+	reopenOnFileChange(previous, "Config reload")
+}
+
+//reopenOnFileChange reopens the log file if a file setting changed since previous. source names the change in the warning
+func reopenOnFileChange(previous fileSettings, source string) {
+	if !isLogFileAttached() || getFileSettings() == previous {
+		return
+	}
+
+	err := ReopenLogFile()
+	if err != nil {
+		Warn(source+": Failed to reopen the log file, still writing to "+GetLogFilePath()+".", err)
+	}
+}
+
+//getConfigFileStamp returns the values used to detect a config file change
+func getConfigFileStamp(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, -1
+	}
+
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,14 @@
+//go:build !js
+
+package xlogging
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//notifyHangup sends SIGHUP to c
+func notifyHangup(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP)
+}
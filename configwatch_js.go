@@ -0,0 +1,7 @@
+package xlogging
+
+import "os"
+
+//notifyHangup js has no SIGHUP, the config is only reloaded when the file changes
+func notifyHangup(c chan<- os.Signal) {
+}
@@ -0,0 +1,78 @@
+package xlogging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+//pointerFileExtension added to the link name for the pointer file used when symlinks are not supported
+const pointerFileExtension = ".path"
+
+//currentLinkName name of the link to the attached log file in the log folder. Empty if off
+var currentLinkName = ""
+
+//SetCurrentLink keeps a symlink with this name (e.g. "current.log") in the log folder that points to the attached log file,
+//so `tail -F logs/current.log` follows rotations. If symlinks are not supported a file named name+".path" with the path of the log file is written instead.
+//Empty turns it off. Updated when the next log file is attached, see ReopenLogFile()
+func SetCurrentLink(name string) error {
+	err := validateCurrentLinkName(name)
+	if err != nil {
+		return err
+	}
+
+	settingsMu.Lock()
+	currentLinkName = name
+	settingsMu.Unlock()
+	return nil
+}
+
+func validateCurrentLinkName(name string) error {
+	if name != "" && filepath.Base(name) != name {
+		return stdError{"Current link '" + name + "' has to be a file name without folder"}
+	}
+	return nil
+}
+
+//isCurrentLinkName true if name is the link or its pointer file
+func isCurrentLinkName(name string) bool {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return currentLinkName != "" && (name == currentLinkName || name == currentLinkName+pointerFileExtension)
+}
+
+//updateCurrentLink points the current link to the log file at path
+func updateCurrentLink(folderPath, path string) error {
+	settingsMu.RLock()
+	name := currentLinkName
+	settingsMu.RUnlock()
+
+	if name == "" {
+		return nil
+	}
+
+	linkPath := filepath.Join(folderPath, name)
+	tmpPath := linkPath + ".tmp"
+
+	//Relative target so the folder can be moved
+	os.Remove(tmpPath)
+	err := os.Symlink(filepath.Base(path), tmpPath)
+	if err == nil {
+		//Rename replaces the old link atomically
+		err = os.Rename(tmpPath, linkPath)
+		if err == nil {
+			os.Remove(linkPath + pointerFileExtension)
+			return nil
+		}
+		os.Remove(tmpPath)
+	}
+
+	//Symlinks not supported, write a pointer file
+	pointerPath := linkPath + pointerFileExtension
+	err = ioutil.WriteFile(pointerPath+".tmp", []byte(path+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(pointerPath+".tmp", pointerPath)
+}
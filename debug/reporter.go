@@ -0,0 +1,167 @@
+package debug
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+
+	xlog "github.com/jasdeepgrewal/xlogging"
+)
+
+//ReportGroup metrics written by the reporter
+type ReportGroup uint
+
+//Report groups
+const (
+	//ReportCPU system cpu usage since the previous report, not in the first report
+	ReportCPU ReportGroup = 1 << iota
+	//ReportMem system memory
+	ReportMem
+	//ReportHeap memory used by the application
+	ReportHeap
+	//ReportGoroutines goroutine count
+	ReportGoroutines
+	//ReportDisk usage of the volumes in ReporterOptions.DiskPaths
+	ReportDisk
+	//ReportAll all groups
+	ReportAll = ReportCPU | ReportMem | ReportHeap | ReportGoroutines | ReportDisk
+)
+
+//DefaultReporterStream stream used when ReporterOptions.Stream is not set
+const DefaultReporterStream byte = 63
+
+//defaultReporterInterval used by StartReporter when interval is 0 or negative
+const defaultReporterInterval = time.Minute
+
+//ReporterOptions options of StartReporter
+type ReporterOptions struct {
+	//Stream InfoS stream of the reports, nil for DefaultReporterStream. StartReporter enables it
+	Stream *byte
+	//Groups reported, 0 reports all
+	Groups ReportGroup
+	//DiskPaths volumes reported by ReportDisk. Default: the log folder
+	DiskPaths []string
+}
+
+//StartReporter writes system and application stats to a stream every interval (1 minute if 0 or negative), starting now.
+//Each report is a "System stats" entry with key=value fields and a "Disk usage" entry for every disk path.
+//Call the returned function to stop it
+func StartReporter(interval time.Duration, opts *ReporterOptions) (stop func()) {
+	if interval <= 0 {
+		interval = defaultReporterInterval
+	}
+
+	o := ReporterOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	stream := DefaultReporterStream
+	if o.Stream != nil {
+		stream = *o.Stream
+	}
+	if o.Groups == 0 {
+		o.Groups = ReportAll
+	}
+	if len(o.DiskPaths) == 0 {
+		if folder, err := xlog.GetLogFolder(); err == nil {
+			o.DiskPaths = []string{folder}
+		}
+	}
+
+	xlog.EnableStream(true, stream)
+
+	if o.Groups&ReportCPU != 0 {
+		//Starts the usage period of the second report
+		cpu.Percent(0, false)
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		//The cpu baseline above was taken just before the first report, cpu usage is reported from the second one
+		first := true
+		for {
+			writeReport(&o, stream, !first)
+			first = false
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopCh)
+			<-done
+		})
+	}
+}
+
+//writeReport writes one report of the selected groups to stream. withCPU is false if there is no usage period yet
+func writeReport(o *ReporterOptions, stream byte, withCPU bool) {
+	var fields []xlog.Field
+
+	if withCPU && o.Groups&ReportCPU != 0 {
+		if percent, err := cpu.Percent(0, false); err == nil && len(percent) > 0 {
+			fields = append(fields, xlog.Field{Key: "cpu_percent", Value: round2(percent[0])})
+		}
+	}
+
+	if o.Groups&ReportMem != 0 {
+		if v, err := mem.VirtualMemory(); err == nil {
+			fields = append(fields,
+				xlog.Field{Key: "mem_total_mb", Value: v.Total / 1048576},
+				xlog.Field{Key: "mem_available_mb", Value: v.Available / 1048576},
+				xlog.Field{Key: "mem_used_percent", Value: round2(v.UsedPercent)})
+		}
+	}
+
+	if o.Groups&ReportHeap != 0 {
+		var m = runtime.MemStats{}
+		runtime.ReadMemStats(&m)
+		fields = append(fields,
+			xlog.Field{Key: "heap_alloc_kb", Value: m.HeapAlloc / 1024},
+			xlog.Field{Key: "heap_sys_kb", Value: m.HeapSys / 1024},
+			xlog.Field{Key: "heap_objects", Value: m.HeapObjects},
+			xlog.Field{Key: "sys_kb", Value: m.Sys / 1024},
+			xlog.Field{Key: "gc_count", Value: m.NumGC})
+	}
+
+	if o.Groups&ReportGoroutines != 0 {
+		fields = append(fields, xlog.Field{Key: "goroutines", Value: runtime.NumGoroutine()})
+	}
+
+	if len(fields) > 0 {
+		xlog.InfoSFields(stream, "System stats", fields...)
+	}
+
+	if o.Groups&ReportDisk != 0 {
+		for _, path := range o.DiskPaths {
+			stat, err := disk.Usage(path)
+			if err != nil {
+				continue
+			}
+			xlog.InfoSFields(stream, "Disk usage",
+				xlog.Field{Key: "path", Value: path},
+				xlog.Field{Key: "disk_total_mb", Value: stat.Total / 1048576},
+				xlog.Field{Key: "disk_free_mb", Value: stat.Free / 1048576},
+				xlog.Field{Key: "disk_used_percent", Value: round2(stat.UsedPercent)})
+		}
+	}
+}
+
+//round2 rounds to 2 decimals so fields stay short
+func round2(f float64) float64 {
+	return float64(int64(f*100+0.5)) / 100
+}
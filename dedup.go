@@ -0,0 +1,126 @@
+package xlogging
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//defaultDedupMaxHold max time repeated entries are held back
+const defaultDedupMaxHold = time.Second * 30
+
+//dedupEnabled collapse consecutive identical entries
+var dedupEnabled = false
+
+//dedupMaxHold max time repeated entries are held back before the repeat line is written
+var dedupMaxHold = defaultDedupMaxHold
+
+//dedupState last written entry and its repeats
+type dedupState struct {
+	last    *entry
+	lastKey string
+	//repeats entries equal to last that were held back
+	repeats int
+	//firstRepeat time of the first held back entry
+	firstRepeat time.Time
+	lastRepeat  time.Time
+	timer       *time.Timer
+}
+
+var dedupMu sync.Mutex
+var dedup dedupState
+
+//SetDedup collapses consecutive identical entries (same level, stream, caller and message) into one "Last message repeated N times" line.
+//Repeats are held back for at most maxHold, 0 uses 30 seconds
+func SetDedup(enable bool, maxHold time.Duration) {
+	if maxHold <= 0 {
+		maxHold = defaultDedupMaxHold
+	}
+
+	settingsMu.Lock()
+	dedupEnabled = enable
+	dedupMaxHold = maxHold
+	settingsMu.Unlock()
+
+	if !enable {
+		FlushDedup()
+	}
+}
+
+//FlushDedup writes the repeat line for held back entries now
+func FlushDedup() {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+
+	flushDedupLocked()
+	dedup.last = nil
+	dedup.lastKey = ""
+}
+
+//dedupAllow returns false if the entry repeats the last one and is held back
+func dedupAllow(e *entry) bool {
+	settingsMu.RLock()
+	enabled := dedupEnabled
+	maxHold := dedupMaxHold
+	settingsMu.RUnlock()
+
+	if !enabled {
+		return true
+	}
+
+	key := getDedupKey(e)
+
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+
+	if dedup.last != nil && key == dedup.lastKey {
+		if dedup.repeats == 0 {
+			dedup.firstRepeat = e.Time
+			dedup.timer = time.AfterFunc(maxHold, onDedupTimer)
+		}
+		dedup.repeats++
+		dedup.lastRepeat = e.Time
+		atomic.AddInt64(&metricDedupDropped, 1)
+		return false
+	}
+
+	flushDedupLocked()
+	dedup.last = e
+	dedup.lastKey = key
+	return true
+}
+
+func onDedupTimer() {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+
+	flushDedupLocked()
+}
+
+//flushDedupLocked writes the repeat line if entries were held back. dedupMu must be held
+func flushDedupLocked() {
+	if dedup.timer != nil {
+		dedup.timer.Stop()
+		dedup.timer = nil
+	}
+
+	if dedup.repeats == 0 || dedup.last == nil {
+		return
+	}
+
+	//Same level, stream and caller as the repeated entry, without the stack
+	repeat := *dedup.last
+	repeat.style &^= stPrintStack
+	repeat.Time = dedup.lastRepeat
+	repeat.Fields = nil
+	repeat.Message = "Last message repeated " + strconv.Itoa(dedup.repeats) + " times over " + dedup.lastRepeat.Sub(dedup.firstRepeat).Round(time.Millisecond).String()
+
+	dedup.repeats = 0
+	outputEntry(&repeat)
+}
+
+//getDedupKey entries with the same key are identical
+func getDedupKey(e *entry) string {
+	return strconv.FormatUint(uint64(e.Level), 10) + "|" + strconv.Itoa(e.Stream) + "|" + e.File + "|" + strconv.Itoa(e.Line) + "|" + e.Message + "|" + formatFields(e.Fields)
+}
@@ -0,0 +1,232 @@
+package xlogging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//DiskGuard free space limits of the log folder volume
+type DiskGuard struct {
+	//LowMB below this old log files are deleted, then Info and InfoS entries are dropped
+	LowMB int64
+	//CriticalMB below this nothing is written to the log file. Terminal output continues
+	CriticalMB int64
+	//KeepFiles log files kept by the cleanup when space is low. 0 uses the MaxFiles setting, or 5 if it is not set
+	KeepFiles int
+	//Interval how often free space is checked. Default 10 seconds
+	Interval time.Duration
+}
+
+//defaultDiskGuardInterval how often free space is checked
+const defaultDiskGuardInterval = time.Second * 10
+
+//defaultDiskGuardKeepFiles log files kept by the cleanup if neither KeepFiles nor MaxFiles is set
+const defaultDiskGuardKeepFiles = 5
+
+//Disk guard states
+const (
+	diskOK int32 = iota
+	diskLow
+	diskCritical
+)
+
+var diskStateNames = []string{"ok", "low", "critical"}
+
+//diskState current disk guard state, read without a lock by every entry
+var diskState = diskOK
+
+//diskDroppedTotal entries not written to the file by the disk guard since start
+var diskDroppedTotal int64
+
+//diskFreeMB free space at the last check, -1 if unknown
+var diskFreeMB int64 = -1
+
+var diskGuardMu sync.Mutex
+var diskGuardStop chan struct{}
+
+//DiskGuardStatus state of the disk guard
+type DiskGuardStatus struct {
+	//State ok, low or critical
+	State string
+	//FreeMB free space at the last check, -1 if unknown
+	FreeMB int64
+	//Dropped entries not written to the file since start
+	Dropped int64
+}
+
+//SetDiskGuard checks the free space of the log folder volume every guard.Interval. nil turns it off.
+//A warning is written to stderr when a limit is crossed, file writes resume when space recovers
+func SetDiskGuard(guard *DiskGuard) error {
+	diskGuardMu.Lock()
+	defer diskGuardMu.Unlock()
+
+	if diskGuardStop != nil {
+		close(diskGuardStop)
+		diskGuardStop = nil
+	}
+
+	if guard == nil {
+		setDiskState(diskOK, -1, nil)
+		return nil
+	}
+
+	if !diskSpaceSupported {
+		return stdError{"DiskGuard: Free space can not be read on this platform"}
+	}
+
+	if guard.LowMB < 0 || guard.CriticalMB < 0 || guard.CriticalMB > guard.LowMB {
+		return stdError{"DiskGuard: CriticalMB must be between 0 and LowMB"}
+	}
+
+	g := *guard
+	if g.Interval <= 0 {
+		g.Interval = defaultDiskGuardInterval
+	}
+
+	stop := make(chan struct{})
+	diskGuardStop = stop
+	go func() {
+		ticker := time.NewTicker(g.Interval)
+		defer ticker.Stop()
+		for {
+			diskGuardMu.Lock()
+			select {
+			case <-stop:
+				//Replaced or turned off while waiting for the lock
+				diskGuardMu.Unlock()
+				return
+			default:
+				checkDiskSpace(&g)
+			}
+			diskGuardMu.Unlock()
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+//GetDiskGuardStatus returns the disk guard state
+func GetDiskGuardStatus() DiskGuardStatus {
+	return DiskGuardStatus{
+		State:   diskStateNames[atomic.LoadInt32(&diskState)],
+		FreeMB:  atomic.LoadInt64(&diskFreeMB),
+		Dropped: atomic.LoadInt64(&diskDroppedTotal),
+	}
+}
+
+//checkDiskSpace updates the state from the free space of the log folder volume
+func checkDiskSpace(g *DiskGuard) {
+	path := GetLogFilePath()
+	if path == "" {
+		return
+	}
+	folderPath := filepath.Dir(path)
+
+	free, err := diskFreeBytes(folderPath)
+	if err != nil {
+		return
+	}
+	freeMB := int64(free / (1024 * 1024))
+
+	if freeMB < g.LowMB {
+		//Retention cleanup first, it may be enough
+		keep := g.KeepFiles
+		if keep <= 0 {
+			settingsMu.RLock()
+			keep = maxLogFiles
+			settingsMu.RUnlock()
+		}
+		if keep <= 0 {
+			keep = defaultDiskGuardKeepFiles
+		}
+		if files, err := ioutil.ReadDir(folderPath); err == nil {
+			fileMu.Lock()
+			if unlock, err := lockLogFolder(folderPath); err == nil {
+				deleteLogFilesOver(folderPath, files, keep)
+				unlock()
+			}
+			fileMu.Unlock()
+		}
+		if free, err = diskFreeBytes(folderPath); err == nil {
+			freeMB = int64(free / (1024 * 1024))
+		}
+	}
+
+	state := diskOK
+	if freeMB < g.CriticalMB {
+		state = diskCritical
+	} else if freeMB < g.LowMB {
+		state = diskLow
+	}
+	setDiskState(state, freeMB, g)
+}
+
+//setDiskState changes the state and writes warnings. g is nil when the guard is turned off
+func setDiskState(state int32, freeMB int64, g *DiskGuard) {
+	atomic.StoreInt64(&diskFreeMB, freeMB)
+	previous := atomic.SwapInt32(&diskState, state)
+	if state == previous {
+		return
+	}
+
+	switch state {
+	case diskLow:
+		if previous == diskOK {
+			fmt.Fprintf(os.Stderr, "[LoggerDisk] Free space %vMB is below %vMB. Info entries are not written to %v\n", freeMB, g.LowMB, GetLogFilePath())
+		}
+	case diskCritical:
+		fmt.Fprintf(os.Stderr, "[LoggerDisk] Free space %vMB is below %vMB. Log file writes are stopped for %v\n", freeMB, g.CriticalMB, GetLogFilePath())
+	case diskOK:
+		if g != nil {
+			fmt.Fprintf(os.Stderr, "[LoggerDisk] Free space recovered (%vMB). Log file writes resumed\n", freeMB)
+			NoFmtf("LOGGER DISK: Free space was low, %v entries were not written since start", atomic.LoadInt64(&diskDroppedTotal))
+		}
+	}
+}
+
+//diskAllow returns false if the entry is dropped because free space is low
+func diskAllow(e *entry) bool {
+	state := atomic.LoadInt32(&diskState)
+	if state == diskOK {
+		return true
+	}
+
+	if e.Level == LevelInfo {
+		atomic.AddInt64(&diskDroppedTotal, 1)
+		return false
+	}
+	if state == diskCritical && isLogFileAttached() {
+		//Still written to the terminal
+		atomic.AddInt64(&diskDroppedTotal, 1)
+	}
+	return true
+}
+
+//fileWriter writes to the log file unless the disk guard stopped file writes
+type fileWriter struct {
+	f *os.File
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&diskState) == diskCritical {
+		return len(p), nil
+	}
+
+	n, err := w.f.Write(p)
+	atomic.AddInt64(&metricBytesWritten, int64(n))
+	if err != nil {
+		atomic.AddInt64(&metricWriteErrors, 1)
+	}
+	return n, err
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+package xlogging
+
+const diskSpaceSupported = false
+
+func diskFreeBytes(path string) (uint64, error) {
+	return 0, stdError{"Free space can not be read on this platform"}
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package xlogging
+
+import "syscall"
+
+const diskSpaceSupported = true
+
+//diskFreeBytes returns the space available to the process on the volume of path
+func diskFreeBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package xlogging
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const diskSpaceSupported = true
+
+var kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+var procGetDiskFreeSpaceEx = kernel32.NewProc("GetDiskFreeSpaceExW")
+
+//diskFreeBytes returns the space available to the process on the volume of path
+func diskFreeBytes(path string) (uint64, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var free uint64
+	r, _, err := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&free)), 0, 0)
+	if r == 0 {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,183 @@
+package xlogging
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+)
+
+//Modes used when dirMode and fileMode are not set. The umask applies
+const (
+	defaultDirMode  os.FileMode = 0755
+	defaultFileMode os.FileMode = 0666
+)
+
+//dirMode mode of created log folders, set exactly (without the umask). 0 uses defaultDirMode
+var dirMode os.FileMode
+
+//fileMode mode of created log files, set exactly (without the umask). 0 uses defaultFileMode
+var fileMode os.FileMode
+
+//fileOwner and fileGroup of created folders and files, -1 keeps the process user and group
+var fileOwner = -1
+var fileGroup = -1
+
+//SetFileModes sets the modes of created log folders and files. They are set exactly, the umask does not apply.
+//0 uses 0755 for folders and 0666 for files with the umask
+func SetFileModes(dir, file os.FileMode) {
+	settingsMu.Lock()
+	dirMode = dir.Perm()
+	fileMode = file.Perm()
+	settingsMu.Unlock()
+}
+
+//SetFileOwner sets the user and group ids of created log folders and files. -1 keeps the process user or group
+func SetFileOwner(uid, gid int) {
+	settingsMu.Lock()
+	fileOwner = uid
+	fileGroup = gid
+	settingsMu.Unlock()
+}
+
+//createLogFolder creates the folder and its parents. Only the log folder gets the configured mode and owner
+func createLogFolder(folderPath string) error {
+	_, err := os.Stat(folderPath)
+	if err == nil {
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return stdError{"Failed to read log folder '" + folderPath + "'. " + err.Error()}
+	}
+
+	settingsMu.RLock()
+	mode, uid, gid := dirMode, fileOwner, fileGroup
+	settingsMu.RUnlock()
+
+	perm := mode
+	if perm == 0 {
+		perm = defaultDirMode
+	}
+
+	err = os.MkdirAll(folderPath, perm)
+	if err == nil {
+		err = setModeAndOwner(folderPath, mode, uid, gid)
+	}
+	if err != nil {
+		return wrapFileError("Failed to create log folder '"+folderPath+"'. ", err)
+	}
+	return nil
+}
+
+//openLogFile opens the log file for appending, a new file gets the configured mode and owner
+func openLogFile(path string) (*os.File, error) {
+	settingsMu.RLock()
+	mode, uid, gid := fileMode, fileOwner, fileGroup
+	settingsMu.RUnlock()
+
+	perm := mode
+	if perm == 0 {
+		perm = defaultFileMode
+	}
+
+	_, errStat := os.Stat(path)
+	created := os.IsNotExist(errStat)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, perm)
+	if err == nil && created {
+		err = setModeAndOwner(path, mode, uid, gid)
+		if err != nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		return nil, wrapFileError("Failed to open log file '"+path+"'. ", err)
+	}
+	return f, nil
+}
+
+//setModeAndOwner mode 0 and ids -1 are not changed
+func setModeAndOwner(path string, mode os.FileMode, uid, gid int) error {
+	if mode != 0 {
+		err := os.Chmod(path, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if uid != -1 || gid != -1 {
+		return os.Chown(path, uid, gid)
+	}
+	return nil
+}
+
+//errReadOnly error of files that can not be written because the file system is read-only
+type errReadOnly struct {
+	stdError
+}
+
+//wrapFileError adds msg to err. Errors of read-only file systems are returned as errReadOnly
+func wrapFileError(msg string, err error) error {
+	if isReadOnlyFS(err) {
+		return errReadOnly{stdError{msg + "The file system is read-only"}}
+	}
+	return stdError{msg + err.Error()}
+}
+
+//isReadOnlyError true if the log file could not be created because the file system is read-only
+func isReadOnlyError(err error) bool {
+	_, ok := err.(errReadOnly)
+	return ok
+}
+
+//parseFileMode parses an octal mode like "0640". Empty returns 0
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, stdError{"Config: Bad file mode '" + s + "', expected octal like 0640"}
+	}
+	return os.FileMode(mode), nil
+}
+
+//formatFileMode returns mode as octal, empty for 0
+func formatFileMode(mode os.FileMode) string {
+	if mode == 0 {
+		return ""
+	}
+	return "0" + strconv.FormatUint(uint64(mode), 8)
+}
+
+//lookupID returns the id of a user or group name, or the id itself if s is a number. Empty returns -1
+func lookupID(s string, group bool) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(s); err == nil {
+		return id, nil
+	}
+
+	var id string
+	if group {
+		g, err := user.LookupGroup(s)
+		if err != nil {
+			return -1, err
+		}
+		id = g.Gid
+	} else {
+		u, err := user.Lookup(s)
+		if err != nil {
+			return -1, err
+		}
+		id = u.Uid
+	}
+
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		//Windows SIDs
+		return -1, stdError{"Config: '" + s + "' has no numeric id"}
+	}
+	return n, nil
+}
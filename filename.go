@@ -0,0 +1,422 @@
+package xlogging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//defaultFileNamePattern Log_17_10_2026
+const defaultFileNamePattern = "{base}_{d}_{M}_{yyyy}"
+
+//fileNamePattern name of log files without the extension, see SetFileNamePattern()
+var fileNamePattern = defaultFileNamePattern
+
+//fileNameRegexp matches names created with fileNamePattern, including rotated ones
+var fileNameRegexp = mustCompileFileNameRegexp(defaultFileNamePattern, fileBaseName, appName, false)
+
+//ownFileNameRegexp like fileNameRegexp, {pid} and {instance} only match this process
+var ownFileNameRegexp = mustCompileFileNameRegexp(defaultFileNamePattern, fileBaseName, appName, true)
+
+//fileNameSortable true if names of fileNamePattern sort by time, see isSortableFileNamePattern()
+var fileNameSortable = isSortableFileNamePattern(defaultFileNamePattern)
+
+//fileBaseName value of {base}
+var fileBaseName = logBaseFileName
+
+//appName value of {app}. Defaults to the executable name
+var appName = getDefaultAppName()
+
+//instanceEnvVar environment variable with the value of {instance}
+const instanceEnvVar = "XLOGGING_INSTANCE"
+
+//instanceID value of {instance}. Defaults to the pid
+var instanceID = os.Getenv(instanceEnvVar)
+
+var instanceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+
+//maxLogFiles old log files are deleted when there are more than this. Ignored if set to 0
+var maxLogFiles = 0
+
+//fileNameTokenFormats time tokens and their go layouts
+var fileNameTokenFormats = map[string]string{
+	"yyyy": "2006",
+	"yy":   "06",
+	"MM":   "01",
+	"M":    "1",
+	"dd":   "02",
+	"d":    "2",
+	"HH":   "15",
+	"mm":   "04",
+	"ss":   "05",
+}
+
+//fileNameTokenRegexps regular expressions matching time tokens
+var fileNameTokenRegexps = map[string]string{
+	"yyyy": `\d{4}`,
+	"yy":   `\d{2}`,
+	"MM":   `\d{2}`,
+	"M":    `\d{1,2}`,
+	"dd":   `\d{2}`,
+	"d":    `\d{1,2}`,
+	"HH":   `\d{2}`,
+	"mm":   `\d{2}`,
+	"ss":   `\d{2}`,
+}
+
+//fileNameTokenRanks significance of time tokens in sortable names, see isSortableFileNamePattern()
+var fileNameTokenRanks = map[string]int{
+	"yyyy": 0,
+	"yy":   0,
+	"MM":   1,
+	"dd":   2,
+	"HH":   3,
+	"mm":   4,
+	"ss":   5,
+}
+
+var fileNameTokenPattern = regexp.MustCompile(`\{[A-Za-z]+\}`)
+
+//SetFileNamePattern sets the name of log files (without extension).
+//Tokens: {base} {app} {host} {pid} {instance} {yyyy} {yy} {MM} {M} {dd} {d} {HH} {mm} {ss}. {M} and {d} are not zero padded.
+//e.g. "{base}-{yyyy}{MM}{dd}-{HH}{mm}" sorts by name. Default "{base}_{d}_{M}_{yyyy}".
+//Files of patterns whose time tokens go from the year down without gaps ({yyyy} {MM} {dd} {HH} {mm} {ss}, no {M} or {d})
+//are ordered by the time in their name, others by modification time.
+//Processes sharing a folder write to the same file unless the pattern has {pid} or {instance}.
+//Used for the next log file, see ReopenLogFile()
+func SetFileNamePattern(pattern string) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	return setFileNamePattern(pattern, fileBaseName, appName)
+}
+
+//SetFileBaseName sets the value of {base}. Default "Log"
+func SetFileBaseName(base string) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	return setFileNamePattern(fileNamePattern, base, appName)
+}
+
+//SetAppName sets the value of {app}. Defaults to the executable name
+func SetAppName(name string) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	return setFileNamePattern(fileNamePattern, fileBaseName, name)
+}
+
+//SetInstanceID sets the value of {instance} (letters, digits, '_', '.', '-'). Empty uses the pid.
+//Defaults to $XLOGGING_INSTANCE
+func SetInstanceID(id string) error {
+	if !instanceIDPattern.MatchString(id) {
+		return stdError{"Instance id '" + id + "' can only contain letters, digits, '_', '.' and '-'"}
+	}
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	previous := instanceID
+	instanceID = id
+	err := setFileNamePattern(fileNamePattern, fileBaseName, appName)
+	if err != nil {
+		instanceID = previous
+	}
+	return err
+}
+
+//getInstanceID returns the value of {instance}. settingsMu must be held
+func getInstanceID() string {
+	if instanceID == "" {
+		return strconv.Itoa(os.Getpid())
+	}
+	return instanceID
+}
+
+//SetMaxLogFiles deletes the oldest log files when there are more than max. 0 keeps all files
+func SetMaxLogFiles(max int) {
+	if max < 0 {
+		max = 0
+	}
+
+	settingsMu.Lock()
+	maxLogFiles = max
+	settingsMu.Unlock()
+}
+
+//setFileNamePattern settingsMu must be held
+func setFileNamePattern(pattern, base, app string) error {
+	re, err := compileFileNameRegexp(pattern, base, app, false)
+	if err != nil {
+		return err
+	}
+	own, err := compileFileNameRegexp(pattern, base, app, true)
+	if err != nil {
+		return err
+	}
+
+	fileNamePattern = pattern
+	fileBaseName = base
+	appName = app
+	fileNameRegexp = re
+	ownFileNameRegexp = own
+	fileNameSortable = isSortableFileNamePattern(pattern)
+	return nil
+}
+
+//isSortableFileNamePattern true if the time tokens of pattern start with the year and follow each other by significance,
+//zero padded and none skipped. The values of those tokens then sort like the times they were created at
+func isSortableFileNamePattern(pattern string) bool {
+	next := 0
+	for _, token := range fileNameTokenPattern.FindAllString(pattern, -1) {
+		name := token[1 : len(token)-1]
+		if _, isTime := fileNameTokenFormats[name]; !isTime {
+			continue
+		}
+		rank, ok := fileNameTokenRanks[name]
+		if !ok || rank != next {
+			return false
+		}
+		next++
+	}
+	return next > 0
+}
+
+//validateFileNamePattern returns an error for unknown tokens or characters not allowed in a file name
+func validateFileNamePattern(pattern string) error {
+	if pattern == "" {
+		return stdError{"File name pattern is empty"}
+	}
+	if strings.ContainsAny(pattern, `/\`) {
+		return stdError{"File name pattern '" + pattern + "' can not contain a path separator"}
+	}
+
+	for _, token := range fileNameTokenPattern.FindAllString(pattern, -1) {
+		name := token[1 : len(token)-1]
+		switch name {
+		case "base", "app", "host", "pid", "instance":
+		default:
+			if _, ok := fileNameTokenFormats[name]; !ok {
+				return stdError{"Unknown file name token '" + token + "'"}
+			}
+		}
+	}
+	return nil
+}
+
+func mustCompileFileNameRegexp(pattern, base, app string, own bool) *regexp.Regexp {
+	re, err := compileFileNameRegexp(pattern, base, app, own)
+	if err != nil {
+		panic(err)
+	}
+	return re
+}
+
+//compileFileNameRegexp returns a regular expression matching all names of the pattern.
+//{pid} and {instance} match any value so files of earlier runs are found, or only the values of this process if own is set.
+//Time tokens are the only groups, see getFileNameTime()
+func compileFileNameRegexp(pattern, base, app string, own bool) (*regexp.Regexp, error) {
+	err := validateFileNamePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var strBuffer bytes.Buffer
+	strBuffer.WriteString("^")
+	last := 0
+	for _, loc := range fileNameTokenPattern.FindAllStringIndex(pattern, -1) {
+		strBuffer.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		name := pattern[loc[0]+1 : loc[1]-1]
+		switch name {
+		case "base":
+			strBuffer.WriteString(regexp.QuoteMeta(base))
+		case "app":
+			strBuffer.WriteString(regexp.QuoteMeta(app))
+		case "host":
+			strBuffer.WriteString(regexp.QuoteMeta(getHostName()))
+		case "pid":
+			if own {
+				strBuffer.WriteString(strconv.Itoa(os.Getpid()))
+			} else {
+				strBuffer.WriteString(`\d+`)
+			}
+		case "instance":
+			if own {
+				strBuffer.WriteString(regexp.QuoteMeta(getInstanceID()))
+			} else {
+				strBuffer.WriteString(`[A-Za-z0-9_.-]+`)
+			}
+		default:
+			strBuffer.WriteString("(" + fileNameTokenRegexps[name] + ")")
+		}
+		last = loc[1]
+	}
+	strBuffer.WriteString(regexp.QuoteMeta(pattern[last:]))
+	//Rotated files: _1 (RotateCounter), _20261017T153000.000 (RotateTimestamp), .log.1 (RotateShift)
+	strBuffer.WriteString(`(?:_\d{8}T\d{6}\.\d{3})?(?:_\d+)?`)
+	strBuffer.WriteString(regexp.QuoteMeta(logFileExtension))
+	strBuffer.WriteString(`(?:\.\d+)?$`)
+
+	return regexp.Compile(strBuffer.String())
+}
+
+//formatFileName returns the file name (without extension) of pattern for time t
+func formatFileName(pattern, base, app string, t time.Time) string {
+	return fileNameTokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		name := token[1 : len(token)-1]
+		switch name {
+		case "base":
+			return base
+		case "app":
+			return app
+		case "host":
+			return getHostName()
+		case "pid":
+			return strconv.Itoa(os.Getpid())
+		case "instance":
+			return getInstanceID()
+		}
+		return t.Format(fileNameTokenFormats[name])
+	})
+}
+
+//isLogFileName true if name was created by the file name pattern
+func isLogFileName(name string) bool {
+	if isCurrentLinkName(name) {
+		return false
+	}
+
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return fileNameRegexp.MatchString(name)
+}
+
+//isOwnLogFileName true if name was created by the file name pattern for this process (see {pid} and {instance})
+func isOwnLogFileName(name string) bool {
+	if isCurrentLinkName(name) {
+		return false
+	}
+
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return ownFileNameRegexp.MatchString(name)
+}
+
+//getFileNameTime returns the values of the time tokens in a name matched by re, empty if it does not match
+func getFileNameTime(re *regexp.Regexp, name string) string {
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return strings.Join(m[1:], "")
+}
+
+//isLogFileOlder true if a was created before b. By the time in their names if the pattern is sortable,
+//then by modification time (rotated files of the same name) and name
+func isLogFileOlder(a, b os.FileInfo, re *regexp.Regexp, sortable bool) bool {
+	if sortable {
+		ta, tb := getFileNameTime(re, a.Name()), getFileNameTime(re, b.Name())
+		if ta != tb {
+			return ta < tb
+		}
+	}
+
+	ta, tb := a.ModTime(), b.ModTime()
+	if ta.Equal(tb) {
+		return a.Name() < b.Name()
+	}
+	return ta.Before(tb)
+}
+
+//getLogFiles returns the files that match the file name pattern, oldest first
+func getLogFiles(files []os.FileInfo) []os.FileInfo {
+	logFiles := make([]os.FileInfo, 0, len(files))
+	for i := range files {
+		if files[i].Mode().IsRegular() && isLogFileName(files[i].Name()) {
+			logFiles = append(logFiles, files[i])
+		}
+	}
+
+	settingsMu.RLock()
+	re, sortable := fileNameRegexp, fileNameSortable
+	settingsMu.RUnlock()
+
+	sort.SliceStable(logFiles, func(i, j int) bool {
+		return isLogFileOlder(logFiles[i], logFiles[j], re, sortable)
+	})
+
+	return logFiles
+}
+
+//getLatestFile returns the newest log file of this process, nil if there is none
+func getLatestFile(files []os.FileInfo) os.FileInfo {
+	settingsMu.RLock()
+	re, sortable := ownFileNameRegexp, fileNameSortable
+	settingsMu.RUnlock()
+
+	var latest os.FileInfo
+	for i := range files {
+		if !files[i].Mode().IsRegular() || !isOwnLogFileName(files[i].Name()) {
+			continue
+		}
+
+		if latest == nil || isLogFileOlder(latest, files[i], re, sortable) {
+			latest = files[i]
+		}
+	}
+
+	return latest
+}
+
+//deleteOldLogFiles deletes the oldest log files if there are more than maxLogFiles. The attached file is never deleted
+func deleteOldLogFiles(folderPath string, files []os.FileInfo) error {
+	settingsMu.RLock()
+	max := maxLogFiles
+	settingsMu.RUnlock()
+
+	return deleteLogFilesOver(folderPath, files, max)
+}
+
+//deleteLogFilesOver deletes the oldest log files if there are more than max. Ignored if max is 0
+func deleteLogFilesOver(folderPath string, files []os.FileInfo, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	logFiles := getLogFiles(files)
+	attachedPath := GetLogFilePath()
+	var firstErr error
+	for i := 0; i < len(logFiles)-max; i++ {
+		path := filepath.Join(folderPath, logFiles[i].Name())
+		if path == attachedPath {
+			continue
+		}
+		err := os.Remove(path)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func getHostName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+func getDefaultAppName() string {
+	name := filepath.Base(os.Args[0])
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
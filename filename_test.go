@@ -0,0 +1,333 @@
+package xlogging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "xlogging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func writeFile(t *testing.T, path, text string, modTime time.Time) {
+	err := ioutil.WriteFile(path, []byte(text), 0644)
+	if err == nil {
+		err = os.Chtimes(path, modTime, modTime)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+//readDir returns the files in dir
+func readDir(t *testing.T, dir string) []os.FileInfo {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func fileNames(files []os.FileInfo) []string {
+	names := make([]string, len(files))
+	for i := range files {
+		names[i] = files[i].Name()
+	}
+	return names
+}
+
+//setTestFileNamePattern sets the file name pattern with the base "Log" until the test ends
+func setTestFileNamePattern(t *testing.T, pattern string) {
+	settingsMu.Lock()
+	previous, base, app := fileNamePattern, fileBaseName, appName
+	err := setFileNamePattern(pattern, "Log", app)
+	settingsMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		setFileNamePattern(previous, base, app)
+		settingsMu.Unlock()
+	})
+}
+
+//setTestLogFolder points the log folder to a temp dir until the test ends. The attached file does not change
+func setTestLogFolder(t *testing.T) string {
+	dir := tempDir(t)
+
+	settingsMu.RLock()
+	folder, base := logFolder, logFolderBase
+	settingsMu.RUnlock()
+
+	SetLogFolder(dir, FolderWorkingDir)
+	t.Cleanup(func() { SetLogFolder(folder, base) })
+	return dir
+}
+
+//attachTestFile attaches path until the test ends
+func attachTestFile(t *testing.T, path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachedMu.Lock()
+	previous := attached
+	attached = attachedFile{f: f, path: path}
+	attachedMu.Unlock()
+
+	t.Cleanup(func() {
+		attachedMu.Lock()
+		attached = previous
+		attachedMu.Unlock()
+		f.Close()
+	})
+}
+
+func TestCompileFileNameRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+		time    string
+	}{
+		{defaultFileNamePattern, "Log_9_10_2026.log", true, "9102026"},
+		{defaultFileNamePattern, "Log_19_10_2026_1.log", true, "19102026"},
+		{defaultFileNamePattern, "Log_19_10_2026_20261019T153000.000.log", true, "19102026"},
+		{defaultFileNamePattern, "Log_19_10_2026_20261019T153000.000_1.log", true, "19102026"},
+		{defaultFileNamePattern, "Log_19_10_2026.log.2", true, "19102026"},
+		{defaultFileNamePattern, "Log_19_10_2026.log.gz", false, ""},
+		{defaultFileNamePattern, "Log_19_10_2026.txt", false, ""},
+		{defaultFileNamePattern, "Other_19_10_2026.log", false, ""},
+		{defaultFileNamePattern, "Log_19_10_2026_x.log", false, ""},
+		{"{base}_{yyyy}-{MM}-{dd}", "Log_2026-10-09.log", true, "20261009"},
+		{"{base}_{yyyy}-{MM}-{dd}", "Log_2026-10-09_3.log", true, "20261009"},
+		{"{base}_{yyyy}-{MM}-{dd}", "Log_2026-10-9.log", false, ""},
+		{"{base}.{yyyy}{MM}{dd}-{HH}{mm}", "Log.20261009-1530.log.1", true, "202610091530"},
+		{"{base}.{yyyy}{MM}{dd}-{HH}{mm}", "Log_20261009-1530.log", false, ""},
+		{"{app}-{base}", "app-Log.log", true, ""},
+		{"{base}-{pid}", "Log-123.log", true, ""},
+		{"{base}-{pid}", "Log-.log", false, ""},
+	}
+	for _, test := range tests {
+		re, err := compileFileNameRegexp(test.pattern, "Log", "app", false)
+		if err != nil {
+			t.Fatalf("%q: %v", test.pattern, err)
+		}
+		if match := re.MatchString(test.name); match != test.match {
+			t.Errorf("%q matches %q = %v, want %v", test.pattern, test.name, match, test.match)
+		}
+		if got := getFileNameTime(re, test.name); got != test.time {
+			t.Errorf("%q time of %q = %q, want %q", test.pattern, test.name, got, test.time)
+		}
+	}
+
+	for _, pattern := range []string{"", "{base}/{yyyy}", "{base}_{week}"} {
+		_, err := compileFileNameRegexp(pattern, "Log", "app", false)
+		if err == nil {
+			t.Errorf("%q: no error", pattern)
+		}
+	}
+}
+
+func TestCompileFileNameRegexpOwn(t *testing.T) {
+	re, err := compileFileNameRegexp("{base}-{pid}", "Log", "app", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	own := formatFileName("{base}-{pid}", "Log", "app", time.Now()) + logFileExtension
+	if !re.MatchString(own) {
+		t.Errorf("own name %q does not match", own)
+	}
+	if re.MatchString("Log-0.log") {
+		t.Error("name of another process matches")
+	}
+}
+
+func TestIsSortableFileNamePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		sortable bool
+	}{
+		{defaultFileNamePattern, false},
+		{"{base}_{yyyy}-{MM}-{dd}", true},
+		{"{base}_{yy}{MM}{dd}_{HH}{mm}{ss}", true},
+		{"{base}-{yyyy}{MM}{dd}-{HH}{mm}", true},
+		{"{app}-{pid}-{yyyy}-{MM}", true},
+		{"{base}_{yyyy}", true},
+		{"{base}_{yyyy}-{M}-{d}", false},
+		{"{base}_{yyyy}-{dd}", false},
+		{"{base}_{dd}-{MM}-{yyyy}", false},
+		{"{base}_{MM}-{dd}", false},
+		{"{base}", false},
+		{"{base}-{pid}", false},
+	}
+	for _, test := range tests {
+		if sortable := isSortableFileNamePattern(test.pattern); sortable != test.sortable {
+			t.Errorf("isSortableFileNamePattern(%q) = %v, want %v", test.pattern, sortable, test.sortable)
+		}
+	}
+}
+
+func TestGetLogFiles(t *testing.T) {
+	type file struct {
+		name string
+		age  time.Duration
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		files   []file
+		want    []string
+	}{
+		{
+			name:    "not sortable by modification time",
+			pattern: defaultFileNamePattern,
+			files: []file{
+				{"Log_10_10_2026.log", 4 * time.Hour},
+				{"Log_9_10_2026.log", 2 * time.Hour},
+				{"Log_9_10_2026_1.log", 3 * time.Hour},
+				{"Log_19_10_2026_20261019T120000.000.log", time.Hour},
+				{"Log_19_10_2026.log.1", 30 * time.Minute},
+				{"Log_19_10_2026.log", 0},
+				{"Other_19_10_2026.log", 0},
+				{"notes.txt", 0},
+			},
+			want: []string{"Log_10_10_2026.log", "Log_9_10_2026_1.log", "Log_9_10_2026.log", "Log_19_10_2026_20261019T120000.000.log", "Log_19_10_2026.log.1", "Log_19_10_2026.log"},
+		},
+		{
+			name:    "not sortable same modification time by name",
+			pattern: defaultFileNamePattern,
+			files: []file{
+				{"Log_9_10_2026.log", time.Hour},
+				{"Log_10_10_2026.log", time.Hour},
+				{"Log_10_10_2026_1.log", time.Hour},
+			},
+			want: []string{"Log_10_10_2026.log", "Log_10_10_2026_1.log", "Log_9_10_2026.log"},
+		},
+		{
+			name:    "sortable by the time in the name",
+			pattern: "{base}_{yyyy}-{MM}-{dd}",
+			files: []file{
+				{"Log_2026-10-09.log", 0},
+				{"Log_2026-10-10_1.log", 4 * time.Hour},
+				{"Log_2026-10-10_20261010T120000.000.log", 3 * time.Hour},
+				{"Log_2026-10-10.log.1", 2 * time.Hour},
+				{"Log_2026-10-10.log", time.Hour},
+				{"Log_2026-10-08.log", time.Hour},
+				{"Log_2026-10.log", 0},
+			},
+			want: []string{"Log_2026-10-08.log", "Log_2026-10-09.log", "Log_2026-10-10_1.log", "Log_2026-10-10_20261010T120000.000.log", "Log_2026-10-10.log.1", "Log_2026-10-10.log"},
+		},
+		{
+			name:    "sortable same modification time",
+			pattern: "{base}-{yyyy}{MM}{dd}-{HH}{mm}",
+			files: []file{
+				{"Log-20261010-0900.log", time.Hour},
+				{"Log-20261009-2300.log", time.Hour},
+				{"Log-20261010-1000.log", time.Hour},
+			},
+			want: []string{"Log-20261009-2300.log", "Log-20261010-0900.log", "Log-20261010-1000.log"},
+		},
+	}
+
+	now := time.Now()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestFileNamePattern(t, test.pattern)
+			dir := tempDir(t)
+			for _, f := range test.files {
+				writeFile(t, filepath.Join(dir, f.name), "", now.Add(-f.age))
+			}
+
+			files := readDir(t, dir)
+			if got := fileNames(getLogFiles(files)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getLogFiles = %q, want %q", got, test.want)
+			}
+
+			latest := getLatestFile(files)
+			if latest == nil || latest.Name() != test.want[len(test.want)-1] {
+				t.Errorf("getLatestFile = %v, want %q", latest, test.want[len(test.want)-1])
+			}
+		})
+	}
+}
+
+func TestGetLatestFileNone(t *testing.T) {
+	setTestFileNamePattern(t, defaultFileNamePattern)
+	dir := tempDir(t)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "", time.Now())
+	os.Mkdir(filepath.Join(dir, "Log_19_10_2026.log"), 0755)
+
+	if latest := getLatestFile(readDir(t, dir)); latest != nil {
+		t.Errorf("getLatestFile = %q, want nil", latest.Name())
+	}
+}
+
+func TestDeleteLogFilesOver(t *testing.T) {
+	names := []string{"Log_2026-10-05.log", "Log_2026-10-06.log", "Log_2026-10-07.log", "Log_2026-10-08.log", "Log_2026-10-09.log"}
+	others := []string{"Other_2026-10-01.log", "Log_2026-10-01.txt", "Log_2026-10-01.log.gz"}
+	tests := []struct {
+		name     string
+		max      int
+		attached string
+		want     []string
+	}{
+		{"off", 0, "", append(append([]string{}, names...), others...)},
+		{"more than files", 10, "", append(append([]string{}, names...), others...)},
+		{"oldest first", 2, "", append(names[3:], others...)},
+		{"attached newest", 1, names[4], append(names[4:], others...)},
+		{"attached kept", 2, names[0], append([]string{names[0], names[3], names[4]}, others...)},
+		{"attached only kept", 1, names[1], append([]string{names[1], names[4]}, others...)},
+	}
+
+	now := time.Now()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestFileNamePattern(t, "{base}_{yyyy}-{MM}-{dd}")
+			dir := tempDir(t)
+			//Modification times in reverse so only the names give the order
+			for i, name := range names {
+				writeFile(t, filepath.Join(dir, name), "", now.Add(time.Duration(-i)*time.Hour))
+			}
+			for _, name := range others {
+				writeFile(t, filepath.Join(dir, name), "", now.Add(-24*time.Hour))
+			}
+			if test.attached != "" {
+				attachTestFile(t, filepath.Join(dir, test.attached))
+			}
+
+			err := deleteLogFilesOver(dir, readDir(t, dir), test.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := fileNames(readDir(t, dir))
+			want := make(map[string]bool, len(test.want))
+			for _, name := range test.want {
+				want[name] = true
+			}
+			if len(got) != len(want) {
+				t.Errorf("files left %q, want %q", got, test.want)
+			}
+			for _, name := range got {
+				if !want[name] {
+					t.Errorf("files left %q, want %q", got, test.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,6 +21,39 @@ var splitRuleSize int64 = 10
 //splitRuleAge split file if it is older than this seconds. Ignored if set to 0
 var splitRuleAge int64 = 3600 //Seconds
 
+//RotationScheme how the previous log file is renamed when a new file is created
+type RotationScheme int
+
+//Rotation schemes
+const (
+	//RotateCounter renames Log.log to the first free Log_1.log, Log_2.log...
+	RotateCounter RotationScheme = iota
+	//RotateTimestamp renames Log.log to Log_20261017T153000.000.log
+	RotateTimestamp
+	//RotateShift renames Log.log.1 to Log.log.2 ... and Log.log to Log.log.1 like logrotate
+	RotateShift
+)
+
+//rotateTimestampFormat suffix of files rotated with RotateTimestamp
+const rotateTimestampFormat = "20060102T150405.000"
+
+//rotationScheme how old files are renamed
+var rotationScheme = RotateCounter
+
+//rotationSchemeNames names used in Config.Rotation
+var rotationSchemeNames = map[string]RotationScheme{
+	"counter":   RotateCounter,
+	"timestamp": RotateTimestamp,
+	"shift":     RotateShift,
+}
+
+//SetRotationScheme sets how the previous log file is renamed when a new file is created
+func SetRotationScheme(scheme RotationScheme) {
+	settingsMu.Lock()
+	rotationScheme = scheme
+	settingsMu.Unlock()
+}
+
 //From seconds conversion conversion
 const (
 	secToMinute = 60
@@ -29,14 +63,64 @@ const (
 
 const (
 	logFileExtension = ".log"
-	logFolderPath    = "logs" //Needs to be read from a json
-	logBaseFileName  = "Log"  //Needs to be read from a json
+	logFolderPath    = "logs" //Default log folder, see SetLogFolder()
+	logBaseFileName  = "Log"  //Default {base} of the file name pattern
 )
 
-//logFileAttached true if log file was attached successfully
-var logFileAttached = false
+//attachedFile the log file entries are written to
+type attachedFile struct {
+	//f nil if no file is attached
+	f    *os.File
+	path string
+}
+
+//attached current log file, read with getAttachedFile()
+var attached attachedFile
+
+//attachedMu guards attached. fileMu serializes the changes
+var attachedMu sync.RWMutex
 
-var logFilePath = ""
+//fileMu serializes attaching and rotating the log file
+var fileMu sync.Mutex
+
+//getAttachedFile returns the attached log file
+func getAttachedFile() attachedFile {
+	attachedMu.RLock()
+	defer attachedMu.RUnlock()
+	return attached
+}
+
+//isLogFileAttached true if a log file was attached successfully
+func isLogFileAttached() bool {
+	return getAttachedFile().f != nil
+}
+
+//GetLogFilePath returns the path of the attached log file. Empty if no file is attached
+func GetLogFilePath() string {
+	return getAttachedFile().path
+}
+
+//ReopenLogFile attaches the log file again using the current file settings (name pattern, split rules...).
+//The previous file is closed
+func ReopenLogFile() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	previous := getAttachedFile()
+	err := setupFileIO()
+	if err != nil {
+		//Still writing to the previous file
+		return err
+	}
+
+	//syncLogFile syncs the attached file holding attachedMu
+	attachedMu.Lock()
+	if previous.f != nil && previous.f != attached.f {
+		previous.f.Close()
+	}
+	attachedMu.Unlock()
+	return nil
+}
 
 func setupFileIO() error {
 	//Get folder path of log file
@@ -45,31 +129,35 @@ func setupFileIO() error {
 		return err
 	}
 
-	//Check if folder exists
-	_, err = os.Stat(folderPath)
-	if os.IsNotExist(err) {
-		//Folder not found, create one
-		err = os.Mkdir(folderPath, os.ModeDir)
-		if err != nil {
-			return err
-		}
-	} else if err != nil {
-		//Folder exists, but there is some other error.
+	//Create the folder and its parents if needed
+	err = createLogFolder(folderPath)
+	if err != nil {
 		return err
 	}
 
+	//Other processes may rotate the same files
+	unlock, err := lockLogFolder(folderPath)
+	if err != nil {
+		fmt.Println("[LoggerInit] Failed to lock log folder, continuing without lock. " + err.Error())
+	} else {
+		defer unlock()
+	}
+
 	logFileName := getLogFileName()
-	var errFilePath error
-	logFilePath, errFilePath = getLogFilePath(logFileName)
+	path, errFilePath := getLogFilePath(logFileName)
 
 	if errFilePath != nil {
 		return errFilePath
 	}
 
-	//fmt.Println("[LoggerInit] LogFilePath: " + logFilePath)
+	//fmt.Println("[LoggerInit] LogFilePath: " + path)
 
-	if splitRuleNewRun {
-		err = rotateAndCheckLogFile()
+	settingsMu.RLock()
+	newRun := splitRuleNewRun
+	settingsMu.RUnlock()
+
+	if newRun {
+		err = rotateAndCheckLogFile(path)
 		if err != nil {
 			return err
 		}
@@ -83,47 +171,84 @@ func setupFileIO() error {
 			latestFile := getLatestFile(files)
 			if latestFile != nil {
 				//Set path to existing file
-				logFilePath = folderPath + string(os.PathSeparator) + latestFile.Name()
-				if checkSplitRuleSize() || checkSplitRuleAge() {
+				path = folderPath + string(os.PathSeparator) + latestFile.Name()
+				if checkSplitRuleSize(path) || checkSplitRuleAge() {
 					fmt.Println("Creating new file")
-					err = rotateAndCheckLogFile()
+					//Rotate the file with the current name, the latest file may have an older name
+					path, err = getLogFilePath(logFileName)
+					if err != nil {
+						return err
+					}
+					err = rotateAndCheckLogFile(path)
 					if err != nil {
 						return err
 					}
 				}
-				//fmt.Println("[LoggerInit] using existing file " + logFilePath)
+				//fmt.Println("[LoggerInit] using existing file " + path)
 			}
 		}
-		//Else: log file will be created with previous(see above in func) set logFilePath.
+		//Else: log file will be created with previous(see above in func) set path.
 	}
 
-	//Create or open the log file at logFilePath
-	f, err := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	//Create or open the log file at path
+	f, err := openLogFile(path)
 	if err == nil {
 		//fmt.Println("[LoggerInit] Logger Log file attached SUCCESSFULLY")
-		logFileAttached = true
-		log.SetOutput(f)
-	} else {
-		logFileAttached = false
-		//fmt.Println("[LoggerInit] Logger failed to find specified file at path " + logFilePath)
-		f.Close()
+		attachedMu.Lock()
+		attached = attachedFile{f: f, path: path}
+		attachedMu.Unlock()
+		writer := &fileWriter{f}
+		logger.SetOutput(writer)
+		settingsMu.RLock()
+		stdLogRedirected := stdLogWriter != nil
+		settingsMu.RUnlock()
+		if !stdLogRedirected {
+			log.SetOutput(writer)
+		}
+
+		err = updateCurrentLink(folderPath, path)
+		if err != nil {
+			fmt.Println("[LoggerInit] Failed to update current log file link. " + err.Error())
+			err = nil
+		}
+
+		if files, errDir := ioutil.ReadDir(folderPath); errDir == nil {
+			err = deleteOldLogFiles(folderPath, files)
+			if err != nil {
+				fmt.Println("[LoggerInit] Failed to delete old log files. " + err.Error())
+				err = nil
+			}
+		}
 	}
+	//Else: the previous file stays attached, none at startup
 	return err
 
 }
 
+//Flush writes held back entries (repeats, an incomplete std log line, rate limit counts) and commits the log file to disk
+func Flush() error {
+	flushStdLog()
+	FlushDedup()
+	flushRateSummary()
+	return syncLogFile()
+}
+
 //retuns true if new file is needed
-func checkSplitRuleSize() bool {
-	if splitRuleSize <= 0 {
+func checkSplitRuleSize(path string) bool {
+	settingsMu.RLock()
+	maxSizeMB := splitRuleSize
+	settingsMu.RUnlock()
+
+	if maxSizeMB <= 0 {
 		return false
 	}
 
-	file, err := os.Stat(logFilePath)
+	file, err := os.Stat(path)
 
 	if err == nil {
 		fileSizeMB := file.Size()
 		fileSizeMB /= 1024 * 1024
-		if fileSizeMB > splitRuleSize {
+		if fileSizeMB > maxSizeMB {
 			return true
 		}
 		return false
@@ -137,29 +262,6 @@ func checkSplitRuleAge() bool {
 	return false
 }
 
-func getLatestFile(files []os.FileInfo) os.FileInfo {
-	index := -1
-	var bestTime int64
-	var currentTime int64
-	for i := range files {
-		if files[i].IsDir() {
-			continue
-		}
-
-		currentTime = files[i].ModTime().Unix()
-		if currentTime > bestTime {
-			index = i
-			bestTime = currentTime
-		}
-	}
-
-	if index > -1 {
-		return files[index]
-	}
-
-	return nil
-}
-
 func getLogFileName() string {
 	return getFileNameNoExt() + logFileExtension
 }
@@ -170,17 +272,10 @@ func getFileNameNoExt() string {
 		t = t.UTC()
 	}
 
-	year, month, day := t.Date()
-	var strBuffer bytes.Buffer
-	strBuffer.WriteString(logBaseFileName)
-	strBuffer.WriteString("_")
-	strBuffer.WriteString(strconv.Itoa(day))
-	strBuffer.WriteString("_")
-	strBuffer.WriteString(strconv.Itoa(int(month)))
-	strBuffer.WriteString("_")
-	strBuffer.WriteString(strconv.Itoa(year))
-
-	return strBuffer.String()
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return formatFileName(fileNamePattern, fileBaseName, appName, t)
 }
 
 func getLogFilePath(fileName string) (string, error) {
@@ -195,20 +290,14 @@ func getLogFilePath(fileName string) (string, error) {
 	return strBuffer.String(), err
 }
 
-func getLogFolderFullPath() (string, error) {
-	folderPath, err := filepath.Abs(logFolderPath)
-
-	return folderPath, err
-}
-
-func rotateAndCheckLogFile() error {
+func rotateAndCheckLogFile(path string) error {
 	err := rotateLogFile()
 	if err != nil {
 		return err
 	}
 
 	//Check if the file exists at path
-	_, err = os.Stat(logFilePath)
+	_, err = os.Stat(path)
 	if err == nil {
 		err = stdError{"Log file already exists. This should not happen.\n RotateXX() should have renamed the existing file."}
 		return err
@@ -222,8 +311,7 @@ func rotateLogFile() error {
 	var err error
 
 	currFileName := getLogFileName()
-	newPath, errFilePath := getLogFilePath(currFileName)
-	currentLogFilePath := newPath
+	currentLogFilePath, errFilePath := getLogFilePath(currFileName)
 
 	if errFilePath != nil {
 		return errFilePath
@@ -237,6 +325,46 @@ func rotateLogFile() error {
 		return err
 	}
 
+	settingsMu.RLock()
+	scheme := rotationScheme
+	settingsMu.RUnlock()
+
+	var newPath string
+	switch scheme {
+	case RotateShift:
+		err = shiftLogFiles(currentLogFilePath)
+		if err == nil {
+			atomic.AddInt64(&metricRotations, 1)
+		}
+		return err
+	case RotateTimestamp:
+		t := time.Now()
+		if useUTC {
+			t = t.UTC()
+		}
+		newPath, err = getFreeLogFilePath(getFileNameNoExt() + "_" + t.Format(rotateTimestampFormat))
+	default:
+		newPath, err = getFreeLogFilePath(getFileNameNoExt())
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(currentLogFilePath, newPath)
+	if err == nil {
+		atomic.AddInt64(&metricRotations, 1)
+	}
+
+	return err
+}
+
+//getFreeLogFilePath returns the path of fileNameNoExt, or of the first fileNameNoExt_1, fileNameNoExt_2... that does not exist
+func getFreeLogFilePath(fileNameNoExt string) (string, error) {
+	newPath, err := getLogFilePath(fileNameNoExt + logFileExtension)
+	if err != nil {
+		return "", err
+	}
+
 	counter := 1
 
 	for err == nil {
@@ -244,17 +372,36 @@ func rotateLogFile() error {
 		if err != nil {
 			//fmt.Println("[LoggerInit] FileRotation: FileNotFound " + newPath)
 		} else {
-			currFileName = getFileNameNoExt() + "_" + strconv.Itoa(counter) + logFileExtension
-			newPath, errFilePath = getLogFilePath(currFileName)
+			var errFilePath error
+			newPath, errFilePath = getLogFilePath(fileNameNoExt + "_" + strconv.Itoa(counter) + logFileExtension)
 			if errFilePath != nil {
-				return errFilePath
+				return "", errFilePath
 			}
 			//fmt.Println("[LoggerInit] FileRotation: UpdatedCheckPath: " + newPath)
 			counter++
 		}
 	}
 
-	err = os.Rename(currentLogFilePath, newPath)
+	return newPath, nil
+}
 
-	return err
+//shiftLogFiles renames path.N to path.N+1 ... path.1 to path.2 and path to path.1
+func shiftLogFiles(path string) error {
+	last := 1
+	for {
+		_, err := os.Stat(path + "." + strconv.Itoa(last))
+		if err != nil {
+			break
+		}
+		last++
+	}
+
+	for i := last - 1; i >= 1; i-- {
+		err := os.Rename(path+"."+strconv.Itoa(i), path+"."+strconv.Itoa(i+1))
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(path, path+".1")
 }
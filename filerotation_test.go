@@ -0,0 +1,128 @@
+package xlogging
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+//setTestRotationScheme sets the rotation scheme until the test ends
+func setTestRotationScheme(t *testing.T, scheme RotationScheme) {
+	settingsMu.RLock()
+	previous := rotationScheme
+	settingsMu.RUnlock()
+
+	SetRotationScheme(scheme)
+	t.Cleanup(func() { SetRotationScheme(previous) })
+}
+
+func TestRotateLogFile(t *testing.T) {
+	type file struct {
+		//name {name} is replaced with the current file name without extension
+		name string
+		text string
+	}
+	tests := []struct {
+		name   string
+		scheme RotationScheme
+		files  []file
+		//want names are regular expressions
+		want []file
+	}{
+		{"no file", RotateCounter, nil, nil},
+		{"counter", RotateCounter,
+			[]file{{"{name}.log", "current"}},
+			[]file{{`{name}_1\.log`, "current"}}},
+		{"counter next free", RotateCounter,
+			[]file{{"{name}.log", "current"}, {"{name}_1.log", "first"}, {"{name}_3.log", "third"}},
+			[]file{{`{name}_1\.log`, "first"}, {`{name}_2\.log`, "current"}, {`{name}_3\.log`, "third"}}},
+		{"timestamp", RotateTimestamp,
+			[]file{{"{name}.log", "current"}, {"{name}_20261009T120000.000.log", "older"}},
+			[]file{{`{name}_20261009T120000\.000\.log`, "older"}, {`{name}_\d{8}T\d{6}\.\d{3}\.log`, "current"}}},
+		{"shift", RotateShift,
+			[]file{{"{name}.log", "current"}, {"{name}.log.1", "first"}, {"{name}.log.2", "second"}},
+			[]file{{`{name}\.log\.1`, "current"}, {`{name}\.log\.2`, "first"}, {`{name}\.log\.3`, "second"}}},
+		{"shift first", RotateShift,
+			[]file{{"{name}.log", "current"}},
+			[]file{{`{name}\.log\.1`, "current"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestFileNamePattern(t, defaultFileNamePattern)
+			setTestRotationScheme(t, test.scheme)
+			dir := setTestLogFolder(t)
+
+			name := getFileNameNoExt()
+			for _, f := range test.files {
+				writeFile(t, filepath.Join(dir, replaceTestName(f.name, name, false)), f.text, time.Now())
+			}
+
+			err := rotateLogFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files := readDir(t, dir)
+			if len(files) != len(test.want) {
+				t.Fatalf("files %q, want %d", fileNames(files), len(test.want))
+			}
+			for _, want := range test.want {
+				re := regexp.MustCompile("^" + replaceTestName(want.name, name, true) + "$")
+				found := false
+				for _, f := range files {
+					text, _ := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+					if re.MatchString(f.Name()) && string(text) == want.text {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("files %q, no match for %s with %q", fileNames(files), re, want.text)
+				}
+			}
+
+			//The current name is free for the new file
+			if len(test.files) > 0 && rotateAndCheckLogFile(filepath.Join(dir, name+logFileExtension)) != nil {
+				t.Error("current file still exists after rotation")
+			}
+		})
+	}
+}
+
+//replaceTestName replaces {name} in s, quoted for a regular expression if quote is set
+func replaceTestName(s, name string, quote bool) string {
+	if quote {
+		name = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(`\{name\}`).ReplaceAllLiteralString(s, name)
+}
+
+func TestShiftLogFiles(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "Log.log")
+	now := time.Now()
+	writeFile(t, path, "0", now)
+	writeFile(t, path+".1", "1", now)
+	writeFile(t, path+".2", "2", now)
+	//Not next to the others, kept
+	writeFile(t, path+".5", "5", now)
+
+	err := shiftLogFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"Log.log.1": "0", "Log.log.2": "1", "Log.log.3": "2", "Log.log.5": "5"}
+	files := readDir(t, dir)
+	if len(files) != len(want) {
+		t.Fatalf("files %q, want %v", fileNames(files), want)
+	}
+	for _, f := range files {
+		text, _ := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if string(text) != want[f.Name()] {
+			t.Errorf("%s = %q, want %q", f.Name(), text, want[f.Name()])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package xlogging
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//FolderBase what a relative log folder is resolved against
+type FolderBase int
+
+//Folder bases
+const (
+	//FolderWorkingDir current working directory
+	FolderWorkingDir FolderBase = iota
+	//FolderExecutable directory of the executable, symlinks resolved
+	FolderExecutable
+	//FolderStateHome $XDG_STATE_HOME/{app} (default ~/.local/state/{app})
+	FolderStateHome
+)
+
+//folderEnvVar environment variable with the log folder used at startup. Absolute or relative to the folder base
+const folderEnvVar = "XLOGGING_FOLDER"
+
+//logFolder log folder, absolute or relative to logFolderBase
+var logFolder = getDefaultLogFolder()
+
+//logFolderBase what a relative logFolder is resolved against
+var logFolderBase = FolderWorkingDir
+
+//folderBaseNames names used in Config.FolderBase
+var folderBaseNames = map[string]FolderBase{
+	"workingDir": FolderWorkingDir,
+	"executable": FolderExecutable,
+	"stateHome":  FolderStateHome,
+}
+
+//SetLogFolder sets the log folder. A relative path is resolved against base, an absolute path is used as is.
+//Default "logs" in the working directory or $XLOGGING_FOLDER. Used for the next log file, see ReopenLogFile()
+func SetLogFolder(path string, base FolderBase) error {
+	if path == "" {
+		return stdError{"Log folder is empty"}
+	}
+
+	settingsMu.Lock()
+	logFolder = path
+	logFolderBase = base
+	settingsMu.Unlock()
+	return nil
+}
+
+//GetLogFolder returns the absolute path of the log folder. It is the folder of the attached file, or where the next file will be created
+func GetLogFolder() (string, error) {
+	if path := GetLogFilePath(); path != "" {
+		return filepath.Dir(path), nil
+	}
+	return getLogFolderFullPath()
+}
+
+func getDefaultLogFolder() string {
+	if folder := os.Getenv(folderEnvVar); folder != "" {
+		return folder
+	}
+	return logFolderPath
+}
+
+func getLogFolderFullPath() (string, error) {
+	settingsMu.RLock()
+	folder, base, app := logFolder, logFolderBase, appName
+	settingsMu.RUnlock()
+
+	if filepath.IsAbs(folder) {
+		return filepath.Clean(folder), nil
+	}
+
+	switch base {
+	case FolderExecutable:
+		exe, err := os.Executable()
+		if err != nil {
+			return "", err
+		}
+		exe, err = filepath.EvalSymlinks(exe)
+		if err != nil {
+			return "", err
+		}
+		folder = filepath.Join(filepath.Dir(exe), folder)
+	case FolderStateHome:
+		stateHome := os.Getenv("XDG_STATE_HOME")
+		if !filepath.IsAbs(stateHome) {
+			//Relative values are invalid by the spec
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			stateHome = filepath.Join(home, ".local", "state")
+		}
+		folder = filepath.Join(stateHome, app, folder)
+	}
+
+	folderPath, err := filepath.Abs(folder)
+
+	return folderPath, err
+}
@@ -0,0 +1,35 @@
+package xlogging
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//folderLockName lock file in the log folder. Processes sharing the folder hold it while they rotate, attach or delete log files
+const folderLockName = ".xlogging.lock"
+
+//lockLogFolder takes the advisory lock of the log folder. Blocks until other processes release it
+func lockLogFolder(folderPath string) (unlock func(), err error) {
+	settingsMu.RLock()
+	perm := fileMode
+	settingsMu.RUnlock()
+	if perm == 0 {
+		perm = defaultFileMode
+	}
+
+	f, err := os.OpenFile(filepath.Join(folderPath, folderLockName), os.O_RDWR|os.O_CREATE, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,52 @@
+package xlogging
+
+//Hook is called for every entry before it is formatted.
+//It can change the entry (message, fields...) and returns false to drop it, later hooks are not called then
+type Hook interface {
+	Fire(e *Entry) bool
+}
+
+//HookFunc is a function used as a Hook
+type HookFunc func(e *Entry) bool
+
+//Fire implements Hook
+func (f HookFunc) Fire(e *Entry) bool {
+	return f(e)
+}
+
+//hooks called in order for each entry
+var hooks []Hook
+
+//AddHook adds a hook to the end of the hook pipeline.
+//Hooks get Info, Warn, Error, InfoS and NoFmt entries (Level == LevelNoFmt)
+func AddHook(h Hook) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	//Copy so running pipelines are not changed
+	hooksNew := make([]Hook, len(hooks), len(hooks)+1)
+	copy(hooksNew, hooks)
+	hooks = append(hooksNew, h)
+}
+
+//ClearHooks removes all hooks
+func ClearHooks() {
+	settingsMu.Lock()
+	hooks = nil
+	settingsMu.Unlock()
+}
+
+//runHooks returns false if a hook dropped the entry
+func runHooks(e *entry) bool {
+	settingsMu.RLock()
+	pipeline := hooks
+	settingsMu.RUnlock()
+
+	for _, h := range pipeline {
+		if !h.Fire(&e.Entry) {
+			return false
+		}
+	}
+
+	return true
+}
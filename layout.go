@@ -0,0 +1,416 @@
+package xlogging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Level is a log type used by the exported api
+type Level uint64
+
+//Exported log types
+const (
+	//LevelInfo Info(), Infof(), InfoS() and InfoSf() logs
+	LevelInfo = Level(logInfo)
+	//LevelWarn Warn() and Warnf() logs
+	LevelWarn = Level(logWarn)
+	//LevelError Error() and Errorf() logs
+	LevelError = Level(logError)
+	//LevelNoFmt NoFmt() and NoFmtf() logs. Only seen by hooks
+	LevelNoFmt = Level(logNoFmt)
+)
+
+//Default level names used by %level
+const (
+	nameInfo  = "INFO"
+	nameWarn  = "WARN"
+	nameError = "ERROR"
+)
+
+//levelPrefixes prefix written before every log line of a type
+var levelPrefixes = map[uint64]string{
+	logInfo:  prefixLog,
+	logWarn:  prefixWarn,
+	logError: prefixError,
+}
+
+//levelNames name written by %level in a pattern
+var levelNames = map[uint64]string{
+	logInfo:  nameInfo,
+	logWarn:  nameWarn,
+	logError: nameError,
+}
+
+//Pattern token types
+const (
+	tokLiteral = iota
+	tokDate
+	tokLevel
+	tokPrefix
+	tokStream
+	tokCaller
+	tokFile
+	tokLine
+	tokFunc
+	tokMessage
+	tokPid
+	tokNewline
+	tokFields
+)
+
+//patternNames pattern keywords. %name or %name{arg}
+var patternNames = map[string]int{
+	"date":    tokDate,
+	"d":       tokDate,
+	"level":   tokLevel,
+	"p":       tokLevel,
+	"prefix":  tokPrefix,
+	"stream":  tokStream,
+	"caller":  tokCaller,
+	"file":    tokFile,
+	"line":    tokLine,
+	"L":       tokLine,
+	"func":    tokFunc,
+	"M":       tokFunc,
+	"message": tokMessage,
+	"msg":     tokMessage,
+	"m":       tokMessage,
+	"pid":     tokPid,
+	"newline": tokNewline,
+	"n":       tokNewline,
+	"fields":  tokFields,
+}
+
+//Named date formats for %date{name}. Any other argument is used as a go time layout
+var dateFormats = map[string]string{
+	"":        "2006/01/02 15:04:05",
+	"iso":     "2006-01-02T15:04:05.000Z07:00",
+	"iso8601": "2006-01-02T15:04:05.000Z07:00",
+	"rfc3339": time.RFC3339,
+	"time":    "15:04:05.000",
+	"unix":    "unix",
+}
+
+type layoutToken struct {
+	kind int
+	//text literal text or date layout
+	text string
+	//width minimum width, padded with spaces. Negative values pad on the right
+	width int
+}
+
+//linePattern compiled pattern, nil uses the default layout
+var linePattern []layoutToken
+
+//linePatternText pattern linePattern was compiled from
+var linePatternText = ""
+
+//patternNeedsCaller true if linePattern writes caller details
+var patternNeedsCaller = false
+
+//patternHasFields true if linePattern has %fields. Fields are written after %message otherwise
+var patternHasFields = false
+
+//SetPattern sets the layout of Info, Warn and Error lines.
+//Similar to log4net ConversionPattern e.g. "%date{iso} [%level] %stream %caller - %message".
+//
+//Keywords: %date{fmt} (%d), %level (%p), %prefix, %stream, %caller, %file, %line (%L), %func (%M), %message (%msg, %m), %fields, %pid, %newline (%n) and %% for '%'.
+//Fields are written after the message if the pattern has no %fields.
+//%date takes iso, rfc3339, time, unix or a go time layout. A width can be set with %5level or %-5level.
+//An empty pattern restores the default layout
+func SetPattern(pattern string) error {
+	tokens, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	settingsMu.Lock()
+	setPattern(pattern, tokens)
+	settingsMu.Unlock()
+	return nil
+}
+
+//setPattern settingsMu must be held
+func setPattern(pattern string, tokens []layoutToken) {
+	needsCaller := false
+	hasFields := false
+	for i := range tokens {
+		switch tokens[i].kind {
+		case tokCaller, tokFile, tokLine, tokFunc:
+			needsCaller = true
+		case tokFields:
+			hasFields = true
+		}
+	}
+
+	linePattern = tokens
+	linePatternText = pattern
+	patternNeedsCaller = needsCaller
+	patternHasFields = hasFields
+}
+
+//GetPattern returns the pattern set by SetPattern
+func GetPattern() string {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return linePatternText
+}
+
+//SetLevelName sets the name written by %level for a log type
+func SetLevelName(level Level, name string) {
+	settingsMu.Lock()
+	levelNames[uint64(level)] = name
+	settingsMu.Unlock()
+}
+
+//SetLevelPrefix sets the prefix ("LOG::", "WARN::", "ERROR! ") written for a log type
+func SetLevelPrefix(level Level, prefix string) {
+	settingsMu.Lock()
+	levelPrefixes[uint64(level)] = prefix
+	settingsMu.Unlock()
+}
+
+func getLevelName(logType uint64) string {
+	if name, ok := levelNames[logType]; ok {
+		return name
+	}
+	return prefixBadFormat
+}
+
+func getLevelPrefix(logType uint64) string {
+	if prefix, ok := levelPrefixes[logType]; ok {
+		return prefix
+	}
+	return prefixBadFormat
+}
+
+//parseLevel converts "info", "warn" or "error" to a log type
+func parseLevel(name string) (uint64, bool) {
+	switch strings.ToLower(name) {
+	case "info", "log":
+		return logInfo, true
+	case "warn", "warning":
+		return logWarn, true
+	case "error":
+		return logError, true
+	}
+	return logNone, false
+}
+
+func parsePattern(pattern string) ([]layoutToken, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	var tokens []layoutToken
+	var literal bytes.Buffer
+
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, layoutToken{kind: tokLiteral, text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i >= len(pattern) {
+			return nil, stdError{"Pattern ends with '%'"}
+		}
+		if pattern[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+
+		//Width
+		leftAlign := false
+		if pattern[i] == '-' {
+			leftAlign = true
+			i++
+		}
+		start := i
+		for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+			i++
+		}
+		width := 0
+		if i > start {
+			width, _ = strconv.Atoi(pattern[start:i])
+		}
+		if leftAlign {
+			width = -width
+		}
+
+		//Keyword
+		start = i
+		for i < len(pattern) && isPatternLetter(pattern[i]) {
+			i++
+		}
+		name := pattern[start:i]
+		kind, ok := patternNames[name]
+		if !ok {
+			return nil, stdError{"Unknown pattern keyword '%" + name + "'"}
+		}
+
+		//Argument
+		arg := ""
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, stdError{"Pattern keyword '%" + name + "' has no closing '}'"}
+			}
+			arg = pattern[i+1 : i+end]
+			i += end
+		} else {
+			i--
+		}
+
+		token := layoutToken{kind: kind, width: width}
+		if kind == tokDate {
+			if layout, ok := dateFormats[arg]; ok {
+				token.text = layout
+			} else {
+				token.text = arg
+			}
+		}
+
+		flushLiteral()
+		tokens = append(tokens, token)
+	}
+	flushLiteral()
+
+	return tokens, nil
+}
+
+func isPatternLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+//formatEntry returns the line for an entry without the trailing new line. settingsMu must be held
+func formatEntry(e *entry) string {
+	if linePattern == nil {
+		return getLinePrefix(e) + " " + getMessage(e, true)
+	}
+
+	var strBuffer bytes.Buffer
+	for i := range linePattern {
+		token := &linePattern[i]
+		if token.kind == tokLiteral {
+			strBuffer.WriteString(token.text)
+			continue
+		}
+		writePadded(&strBuffer, formatToken(e, token), token.width)
+	}
+
+	return strBuffer.String()
+}
+
+func formatToken(e *entry, token *layoutToken) string {
+	switch token.kind {
+	case tokDate:
+		t := e.Time
+		if useUTC {
+			t = t.UTC()
+		}
+		if token.text == "unix" {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+		return t.Format(token.text)
+	case tokLevel:
+		return getLevelName(uint64(e.Level))
+	case tokPrefix:
+		return getLevelPrefix(uint64(e.Level))
+	case tokStream:
+		if e.Stream == noStream {
+			return "-"
+		}
+		return strconv.Itoa(e.Stream)
+	case tokCaller:
+		if !e.callerOK {
+			return "File_Format_Failed"
+		}
+		fileNameType := getFileNameType(e.style)
+		if fileNameType == 0 {
+			fileNameType = 1
+		}
+		return getCallerString(e, fileNameType)
+	case tokFile:
+		if !e.callerOK {
+			return "?"
+		}
+		return filepath.Base(e.File)
+	case tokLine:
+		return strconv.Itoa(e.Line)
+	case tokFunc:
+		if fn := runtime.FuncForPC(e.pc); e.callerOK && fn != nil {
+			return fn.Name()
+		}
+		return "?"
+	case tokMessage:
+		return getMessage(e, !patternHasFields)
+	case tokFields:
+		return formatFields(e.Fields)
+	case tokPid:
+		return strconv.Itoa(os.Getpid())
+	case tokNewline:
+		return "\n"
+	}
+	return ""
+}
+
+func writePadded(strBuffer *bytes.Buffer, s string, width int) {
+	padLeft := width > 0
+	if width < 0 {
+		width = -width
+	}
+
+	pad := width - len(s)
+	if pad > 0 && padLeft {
+		strBuffer.WriteString(strings.Repeat(" ", pad))
+	}
+	strBuffer.WriteString(s)
+	if pad > 0 && !padLeft {
+		strBuffer.WriteString(strings.Repeat(" ", pad))
+	}
+}
+
+//getMessage returns the message, followed by the fields if withFields is set
+func getMessage(e *entry, withFields bool) string {
+	if !withFields || len(e.Fields) == 0 {
+		return e.Message
+	}
+	return e.Message + " " + formatFields(e.Fields)
+}
+
+//formatFields returns fields as key=value separated by spaces
+func formatFields(fields []Field) string {
+	var strBuffer bytes.Buffer
+	for i := range fields {
+		if i > 0 {
+			strBuffer.WriteString(" ")
+		}
+		strBuffer.WriteString(fields[i].Key)
+		strBuffer.WriteString("=")
+		strBuffer.WriteString(formatFieldValue(fields[i].Value))
+	}
+	return strBuffer.String()
+}
+
+func formatFieldValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
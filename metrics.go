@@ -0,0 +1,179 @@
+package xlogging
+
+import (
+	"bytes"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+//Metrics counters of the logger since start
+type Metrics struct {
+	//Entries written entries keyed by level: info, warn, error, nofmt
+	Entries map[string]int64
+	//StreamEntries written InfoS entries keyed by stream
+	StreamEntries map[int]int64
+	//BytesWritten bytes written to log files
+	BytesWritten int64
+	//Rotations log files renamed by rotation
+	Rotations int64
+	//WriteErrors failed writes to the log file
+	WriteErrors int64
+	//Dropped entries not written keyed by reason: hook, ratelimit, dedup, disk
+	Dropped map[string]int64
+	Sync    SyncStats
+}
+
+//Indexes of metricEntries
+const (
+	metricInfo = iota
+	metricWarn
+	metricError
+	metricNoFmt
+)
+
+var metricLevelNames = []string{"info", "warn", "error", "nofmt"}
+
+var metricEntries [4]int64
+var metricStreamEntries [64]int64
+var metricBytesWritten int64
+var metricRotations int64
+var metricWriteErrors int64
+
+//metricHookDropped entries dropped by hooks
+var metricHookDropped int64
+
+//metricDedupDropped repeated entries collapsed by dedup
+var metricDedupDropped int64
+
+//countEntry counts a written entry
+func countEntry(e *entry) {
+	switch e.Level {
+	case LevelInfo:
+		atomic.AddInt64(&metricEntries[metricInfo], 1)
+	case LevelWarn:
+		atomic.AddInt64(&metricEntries[metricWarn], 1)
+	case LevelError:
+		atomic.AddInt64(&metricEntries[metricError], 1)
+	default:
+		atomic.AddInt64(&metricEntries[metricNoFmt], 1)
+	}
+
+	if e.Stream >= 0 && e.Stream < len(metricStreamEntries) {
+		atomic.AddInt64(&metricStreamEntries[e.Stream], 1)
+	}
+}
+
+//GetMetrics returns the logger counters since start
+func GetMetrics() Metrics {
+	m := Metrics{
+		Entries:       make(map[string]int64, len(metricLevelNames)),
+		StreamEntries: map[int]int64{},
+		BytesWritten:  atomic.LoadInt64(&metricBytesWritten),
+		Rotations:     atomic.LoadInt64(&metricRotations),
+		WriteErrors:   atomic.LoadInt64(&metricWriteErrors),
+		Sync:          GetSyncStats(),
+	}
+
+	for i, name := range metricLevelNames {
+		m.Entries[name] = atomic.LoadInt64(&metricEntries[i])
+	}
+	for i := range metricStreamEntries {
+		if n := atomic.LoadInt64(&metricStreamEntries[i]); n > 0 {
+			m.StreamEntries[i] = n
+		}
+	}
+
+	rateMu.Lock()
+	rateDropped := rateSuppressedTotal
+	rateMu.Unlock()
+
+	m.Dropped = map[string]int64{
+		"hook":      atomic.LoadInt64(&metricHookDropped),
+		"ratelimit": rateDropped,
+		"dedup":     atomic.LoadInt64(&metricDedupDropped),
+		"disk":      atomic.LoadInt64(&diskDroppedTotal),
+	}
+
+	return m
+}
+
+//PublishExpvar publishes GetMetrics() as an expvar variable. Empty name uses "xlogging".
+//Does nothing if a variable with the name exists
+func PublishExpvar(name string) {
+	if name == "" {
+		name = "xlogging"
+	}
+	if expvar.Get(name) != nil {
+		return
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return GetMetrics()
+	}))
+}
+
+//MetricsHandler returns a http.Handler writing the logger counters in the Prometheus text format
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(formatPrometheus(GetMetrics()))
+	})
+}
+
+//formatPrometheus writes m in the Prometheus text format
+func formatPrometheus(m Metrics) []byte {
+	var strBuffer bytes.Buffer
+
+	writeHeader := func(name, kind, help string) {
+		fmt.Fprintf(&strBuffer, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, kind)
+	}
+
+	writeHeader("xlogging_entries_total", "counter", "Log entries written by level.")
+	for _, level := range metricLevelNames {
+		fmt.Fprintf(&strBuffer, "xlogging_entries_total{level=%q} %v\n", level, m.Entries[level])
+	}
+
+	writeHeader("xlogging_stream_entries_total", "counter", "InfoS entries written by stream.")
+	streams := make([]int, 0, len(m.StreamEntries))
+	for stream := range m.StreamEntries {
+		streams = append(streams, stream)
+	}
+	sort.Ints(streams)
+	for _, stream := range streams {
+		fmt.Fprintf(&strBuffer, "xlogging_stream_entries_total{stream=%q} %v\n", strconv.Itoa(stream), m.StreamEntries[stream])
+	}
+
+	writeHeader("xlogging_bytes_written_total", "counter", "Bytes written to log files.")
+	fmt.Fprintf(&strBuffer, "xlogging_bytes_written_total %v\n", m.BytesWritten)
+
+	writeHeader("xlogging_rotations_total", "counter", "Log files rotated.")
+	fmt.Fprintf(&strBuffer, "xlogging_rotations_total %v\n", m.Rotations)
+
+	writeHeader("xlogging_write_errors_total", "counter", "Failed writes to the log file.")
+	fmt.Fprintf(&strBuffer, "xlogging_write_errors_total %v\n", m.WriteErrors)
+
+	writeHeader("xlogging_dropped_total", "counter", "Log entries not written by reason.")
+	reasons := make([]string, 0, len(m.Dropped))
+	for reason := range m.Dropped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&strBuffer, "xlogging_dropped_total{reason=%q} %v\n", reason, m.Dropped[reason])
+	}
+
+	writeHeader("xlogging_syncs_total", "counter", "Log file syncs.")
+	fmt.Fprintf(&strBuffer, "xlogging_syncs_total %v\n", m.Sync.Count)
+	writeHeader("xlogging_sync_errors_total", "counter", "Failed log file syncs.")
+	fmt.Fprintf(&strBuffer, "xlogging_sync_errors_total %v\n", m.Sync.Errors)
+	writeHeader("xlogging_sync_seconds_total", "counter", "Time spent syncing the log file.")
+	fmt.Fprintf(&strBuffer, "xlogging_sync_seconds_total %v\n", m.Sync.TotalTime.Seconds())
+	writeHeader("xlogging_sync_max_seconds", "gauge", "Longest log file sync.")
+	fmt.Fprintf(&strBuffer, "xlogging_sync_max_seconds %v\n", m.Sync.MaxTime.Seconds())
+
+	return strBuffer.Bytes()
+}
@@ -0,0 +1,241 @@
+package xlogging
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//RateLimit writes the First entries of every Period, then 1 in Thereafter. Thereafter 0 drops the rest of the period
+type RateLimit struct {
+	First      int
+	Period     time.Duration
+	Thereafter int
+}
+
+//defaultRateSummaryInterval how often suppressed counts are written
+const defaultRateSummaryInterval = time.Minute
+
+//rateCallsite limit for each callsite (caller pc) of Info, Warn and Error. nil if off
+var rateCallsite *RateLimit
+
+//rateStreams limits for InfoS streams. Stream entries use these instead of rateCallsite
+var rateStreams = map[int]*RateLimit{}
+
+//rateSummaryInterval how often suppressed counts are written
+var rateSummaryInterval = defaultRateSummaryInterval
+
+//rateBucket counts entries of one callsite or stream
+type rateBucket struct {
+	periodStart time.Time
+	count       int
+	//suppressed entries dropped since the last summary
+	suppressed int64
+	//where callsite or stream, used in the summary
+	where string
+}
+
+type rateKey struct {
+	pc     uintptr
+	stream int
+}
+
+var rateMu sync.Mutex
+var rateBuckets = map[rateKey]*rateBucket{}
+var rateSummaryStop chan struct{}
+
+//rateSuppressedTotal entries dropped by rate limits since start
+var rateSuppressedTotal int64
+
+//SetRateLimit limits entries per callsite (the line that called Info, Warn, Error...). nil turns it off
+func SetRateLimit(limit *RateLimit) {
+	settingsMu.Lock()
+	rateCallsite = copyRateLimit(limit)
+	settingsMu.Unlock()
+
+	updateRateSummary()
+}
+
+//SetStreamRateLimit limits entries of an InfoS stream. nil turns it off
+func SetStreamRateLimit(stream byte, limit *RateLimit) {
+	if stream > 63 {
+		stream = 63
+	}
+
+	settingsMu.Lock()
+	if limit == nil {
+		delete(rateStreams, int(stream))
+	} else {
+		rateStreams[int(stream)] = copyRateLimit(limit)
+	}
+	settingsMu.Unlock()
+
+	updateRateSummary()
+}
+
+//SetRateLimitSummaryInterval sets how often the number of suppressed entries is written. Default 1 minute
+func SetRateLimitSummaryInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultRateSummaryInterval
+	}
+
+	settingsMu.Lock()
+	rateSummaryInterval = interval
+	settingsMu.Unlock()
+
+	rateMu.Lock()
+	stopRateSummary()
+	rateMu.Unlock()
+	updateRateSummary()
+}
+
+func copyRateLimit(limit *RateLimit) *RateLimit {
+	if limit == nil {
+		return nil
+	}
+	limitCopy := *limit
+	if limitCopy.Period <= 0 {
+		limitCopy.Period = time.Second
+	}
+	return &limitCopy
+}
+
+//isRateLimited true if any rate limit is set. settingsMu must be held
+func isRateLimited() bool {
+	return rateCallsite != nil || len(rateStreams) > 0
+}
+
+//getRateLimit returns the limit of an entry or nil. settingsMu must be held
+func getRateLimit(e *entry) (*RateLimit, rateKey) {
+	if e.Stream != noStream {
+		if limit, ok := rateStreams[e.Stream]; ok {
+			return limit, rateKey{stream: e.Stream}
+		}
+	}
+
+	if rateCallsite != nil && e.callerOK {
+		return rateCallsite, rateKey{pc: e.pc, stream: noStream}
+	}
+
+	return nil, rateKey{}
+}
+
+//rateLimitAllow returns false if the entry has to be dropped
+func rateLimitAllow(e *entry) bool {
+	settingsMu.RLock()
+	limit, key := getRateLimit(e)
+	settingsMu.RUnlock()
+
+	if limit == nil {
+		return true
+	}
+
+	rateMu.Lock()
+	defer rateMu.Unlock()
+
+	bucket, ok := rateBuckets[key]
+	if !ok {
+		bucket = &rateBucket{periodStart: e.Time}
+		if key.stream != noStream {
+			bucket.where = "stream " + strconv.Itoa(key.stream)
+		} else {
+			bucket.where = filepath.Base(e.File) + "(" + strconv.Itoa(e.Line) + ")"
+		}
+		rateBuckets[key] = bucket
+	}
+
+	if e.Time.Sub(bucket.periodStart) >= limit.Period {
+		bucket.periodStart = e.Time
+		bucket.count = 0
+	}
+
+	bucket.count++
+	if bucket.count <= limit.First {
+		return true
+	}
+	if limit.Thereafter > 0 && (bucket.count-limit.First)%limit.Thereafter == 0 {
+		return true
+	}
+
+	bucket.suppressed++
+	rateSuppressedTotal++
+	return false
+}
+
+//updateRateSummary starts or stops writing summaries
+func updateRateSummary() {
+	settingsMu.RLock()
+	limited := isRateLimited()
+	interval := rateSummaryInterval
+	settingsMu.RUnlock()
+
+	rateMu.Lock()
+	if !limited {
+		stopRateSummary()
+		summary := takeRateSummary()
+		rateBuckets = map[rateKey]*rateBucket{}
+		rateMu.Unlock()
+
+		writeRateSummary(summary)
+		return
+	}
+	defer rateMu.Unlock()
+
+	if rateSummaryStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	rateSummaryStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				flushRateSummary()
+			}
+		}
+	}()
+}
+
+//stopRateSummary rateMu must be held
+func stopRateSummary() {
+	if rateSummaryStop != nil {
+		close(rateSummaryStop)
+		rateSummaryStop = nil
+	}
+}
+
+//flushRateSummary writes and resets the suppressed counts
+func flushRateSummary() {
+	rateMu.Lock()
+	summary := takeRateSummary()
+	rateMu.Unlock()
+
+	writeRateSummary(summary)
+}
+
+//takeRateSummary returns the summary lines of the suppressed counts and resets them. rateMu must be held
+func takeRateSummary() []string {
+	var summary []string
+	for _, bucket := range rateBuckets {
+		if bucket.suppressed > 0 {
+			summary = append(summary, "LOGGER RATE LIMIT: Suppressed "+strconv.FormatInt(bucket.suppressed, 10)+" entries from "+bucket.where)
+			bucket.suppressed = 0
+		}
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+//writeRateSummary writes lines of takeRateSummary(). rateMu must not be held, hooks and GetMetrics() take it
+func writeRateSummary(summary []string) {
+	for _, line := range summary {
+		NoFmt(line)
+	}
+}
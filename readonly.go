@@ -0,0 +1,13 @@
+//go:build !plan9
+
+package xlogging
+
+import (
+	"errors"
+	"syscall"
+)
+
+//isReadOnlyFS true if err is caused by a read-only file system
+func isReadOnlyFS(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
@@ -0,0 +1,6 @@
+package xlogging
+
+//isReadOnlyFS plan9 has no EROFS
+func isReadOnlyFS(err error) bool {
+	return false
+}
@@ -0,0 +1,92 @@
+package xlogging
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+//recoverRepanic Recover() panics again after logging
+var recoverRepanic = true
+
+//SetRecoverRepanic sets if Recover() and Go() panic again after the panic is logged (default) or swallow it
+func SetRecoverRepanic(repanic bool) {
+	settingsMu.Lock()
+	recoverRepanic = repanic
+	settingsMu.Unlock()
+}
+
+//Recover logs a panic with its stack as an Error and flushes the log file.
+//Then panics again or returns depending on SetRecoverRepanic(). Has to be deferred: defer xlogging.Recover()
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	logPanic(r)
+
+	settingsMu.RLock()
+	repanic := recoverRepanic
+	settingsMu.RUnlock()
+
+	if repanic {
+		panic(r)
+	}
+}
+
+//Go runs fn in a new goroutine. Panics in fn are handled by Recover()
+func Go(fn func()) {
+	go func() {
+		defer Recover()
+		fn()
+	}()
+}
+
+func logPanic(r interface{}) {
+	e, _ := makeEntry(logError, noStream, fmt.Sprint("PANIC: ", r))
+	e.pc, e.File, e.Line, e.callerOK = panicOrigin()
+	e.style |= stPrintStack
+	e.stack = string(debug.Stack())
+
+	if err, ok := r.(error); ok {
+		_, e.Fields = getErrorDetails([]interface{}{err})
+	}
+
+	//Panic values often hold tokens and request dumps
+	redactEntry(e)
+	if !runHooks(e) {
+		atomic.AddInt64(&metricHookDropped, 1)
+		Flush()
+		return
+	}
+
+	//Not rate limited or collapsed, a panic is always written
+	FlushDedup()
+	outputEntry(e)
+	Flush()
+}
+
+//panicOrigin returns the frame that panicked. Called from a deferred function
+func panicOrigin() (pc uintptr, file string, line int, ok bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame.PC, frame.File, frame.Line, true
+		}
+		if !more {
+			break
+		}
+	}
+
+	return 0, "", 0, false
+}
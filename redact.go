@@ -0,0 +1,185 @@
+package xlogging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//defaultRedactMask replaces redacted text
+const defaultRedactMask = "[REDACTED]"
+
+//redactBuiltins patterns that can be enabled by name in RedactConfig.Builtins
+var redactBuiltins = map[string]string{
+	"bearer":     `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	"creditcard": `\b(?:\d[ -]?){12,18}\d\b`,
+	"email":      `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+//redactBuiltinChecks builtins whose matches are only masked if the check passes
+var redactBuiltinChecks = map[string]func(string) bool{
+	"creditcard": isLuhnValid,
+}
+
+//RedactConfig masks secrets in messages and fields before they are written
+type RedactConfig struct {
+	//Builtins names of built in patterns: "bearer", "creditcard" (13 to 19 digits passing the Luhn check), "email"
+	Builtins []string `json:"builtins"`
+	//Patterns regular expressions, matches are masked
+	Patterns []string `json:"patterns"`
+	//Fields sensitive names, case insensitive. Field values with this key are masked,
+	//as are values written as name=value or name: value in messages (e.g. a struct printed with %+v)
+	Fields []string `json:"fields"`
+	//Mask replaces redacted text. Default "[REDACTED]"
+	Mask string `json:"mask"`
+}
+
+//redactor compiled RedactConfig
+type redactor struct {
+	patterns []redactPattern
+	//fieldPattern matches name=value in messages, nil if there are no field names
+	fieldPattern *regexp.Regexp
+	fields       map[string]bool
+	mask         string
+}
+
+//redactPattern pattern of a redactor
+type redactPattern struct {
+	re *regexp.Regexp
+	//check masks a match only if it returns true, nil masks all matches
+	check func(string) bool
+}
+
+//redaction active redactor, nil if off
+var redaction *redactor
+
+//SetRedaction enables redaction of messages and fields. nil turns it off
+func SetRedaction(config *RedactConfig) error {
+	r, err := newRedactor(config)
+	if err != nil {
+		return err
+	}
+
+	settingsMu.Lock()
+	redaction = r
+	settingsMu.Unlock()
+	return nil
+}
+
+func newRedactor(config *RedactConfig) (*redactor, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	r := &redactor{mask: config.Mask, fields: make(map[string]bool, len(config.Fields))}
+	if r.mask == "" {
+		r.mask = defaultRedactMask
+	}
+
+	for _, name := range config.Builtins {
+		pattern, ok := redactBuiltins[strings.ToLower(name)]
+		if !ok {
+			return nil, stdError{"Redact: Unknown builtin '" + name + "'"}
+		}
+		r.patterns = append(r.patterns, redactPattern{regexp.MustCompile(pattern), redactBuiltinChecks[strings.ToLower(name)]})
+	}
+
+	for _, pattern := range config.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, stdError{"Redact: Bad pattern '" + pattern + "': " + err.Error()}
+		}
+		r.patterns = append(r.patterns, redactPattern{re, nil})
+	}
+
+	if len(config.Fields) > 0 {
+		names := make([]string, len(config.Fields))
+		for i, name := range config.Fields {
+			r.fields[strings.ToLower(name)] = true
+			names[i] = regexp.QuoteMeta(name)
+		}
+		//name, separator (= : ":"), value up to a separator
+		r.fieldPattern = regexp.MustCompile(`(?i)(\b(?:` + strings.Join(names, "|") + `)["']?\s*[:=]\s*["']?)([^\s,;&"'}\]]+)`)
+	}
+
+	return r, nil
+}
+
+//redactEntry masks the message and fields of an entry
+func redactEntry(e *entry) {
+	settingsMu.RLock()
+	r := redaction
+	settingsMu.RUnlock()
+
+	if r == nil {
+		return
+	}
+
+	e.Message = r.redactString(e.Message)
+
+	if len(e.Fields) == 0 {
+		return
+	}
+
+	//Copy, the fields may be shared (e.g. by a slog handler)
+	fields := make([]Field, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field
+		if r.fields[strings.ToLower(field.Key)] {
+			fields[i].Value = r.mask
+			continue
+		}
+
+		s, isString := field.Value.(string)
+		if !isString {
+			s = fmt.Sprint(field.Value)
+		}
+		if redacted := r.redactString(s); redacted != s || isString {
+			fields[i].Value = redacted
+		}
+	}
+	e.Fields = fields
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, p := range r.patterns {
+		if p.check == nil {
+			s = p.re.ReplaceAllLiteralString(s, r.mask)
+			continue
+		}
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.check(match) {
+				return r.mask
+			}
+			return match
+		})
+	}
+
+	if r.fieldPattern != nil {
+		s = r.fieldPattern.ReplaceAllString(s, "${1}"+strings.Replace(r.mask, "$", "$$", -1))
+	}
+
+	return s
+}
+
+//isLuhnValid true if the digits of s pass the Luhn check of card numbers. Other characters are skipped
+func isLuhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+
+		digit := int(s[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,153 @@
+//go:build go1.21
+
+package xlogging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+//SlogHandlerOptions options for NewSlogHandler
+type SlogHandlerOptions struct {
+	//Level minimum slog level that is logged. Defaults to slog.LevelInfo
+	Level slog.Leveler
+	//UseStream writes records below slog.LevelWarn to Stream like InfoS() does
+	UseStream bool
+	//Stream InfoS stream (0-63) used if UseStream is set
+	Stream byte
+}
+
+//SlogHandler is a slog.Handler that writes through xlogging.
+//Debug and Info records are written as LOG, Warn as WARN and Error as ERROR.
+//Attrs are written as fields, keys inside groups are prefixed with the group name ("group.key")
+type SlogHandler struct {
+	opts   SlogHandlerOptions
+	fields []Field
+	//groupPrefix prefix of keys added after WithGroup, e.g. "request."
+	groupPrefix string
+}
+
+//NewSlogHandler returns a slog.Handler that writes to the xlogging file. opts can be nil
+func NewSlogHandler(opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Stream > 63 {
+		h.opts.Stream = 63
+	}
+
+	return h
+}
+
+//Enabled implements slog.Handler
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	if level < minLevel {
+		return false
+	}
+
+	logType := slogToLogType(level)
+	if !canLog(logType) {
+		return false
+	}
+
+	if logType == logInfo && h.opts.UseStream {
+		return isStreamEnabled(h.opts.Stream)
+	}
+
+	return true
+}
+
+//Handle implements slog.Handler
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	logType := slogToLogType(r.Level)
+	stream := noStream
+	if logType == logInfo && h.opts.UseStream {
+		stream = int(h.opts.Stream)
+	}
+
+	e, needsCaller := makeEntry(logType, stream, r.Message)
+	if !r.Time.IsZero() {
+		e.Time = r.Time
+	}
+
+	if needsCaller && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.pc, e.File, e.Line = frame.PC, frame.File, frame.Line
+		e.callerOK = frame.File != ""
+	}
+
+	fields := make([]Field, len(h.fields), len(h.fields)+r.NumAttrs())
+	copy(fields, h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.groupPrefix, a)
+		return true
+	})
+	e.Fields = fields
+
+	writeEntry(e)
+	return nil
+}
+
+//WithAttrs implements slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := *h
+	h2.fields = make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(h2.fields, h.fields)
+	for _, a := range attrs {
+		h2.fields = appendSlogAttr(h2.fields, h.groupPrefix, a)
+	}
+
+	return &h2
+}
+
+//WithGroup implements slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.groupPrefix = h.groupPrefix + name + "."
+	return &h2
+}
+
+func slogToLogType(level slog.Level) uint64 {
+	switch {
+	case level >= slog.LevelError:
+		return logError
+	case level >= slog.LevelWarn:
+		return logWarn
+	}
+	return logInfo
+}
+
+//appendSlogAttr appends a as fields. Groups are flattened into "group.key"
+func appendSlogAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendSlogAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
@@ -0,0 +1,156 @@
+package xlogging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+//packagePath import path of this package, used to skip its frames when looking for a caller
+const packagePath = "github.com/jasdeepgrewal/xlogging"
+
+//lineWriter turns every line written to it into a log entry
+type lineWriter struct {
+	logType uint64
+	stream  int
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+//stdLogWriter writer of the standard logger after RedirectStdLog(), nil if it is not redirected.
+//setupFileIO does not change the standard logger output while it is set
+var stdLogWriter *lineWriter
+
+//Writer returns an io.Writer that logs every written line as a level entry.
+//Use it for libraries that take an io.Writer for their output. Close writes a last line that has no new line
+func Writer(level Level) io.WriteCloser {
+	return &lineWriter{logType: uint64(level), stream: noStream}
+}
+
+//NewStdLogger returns a *log.Logger that logs every line as a level entry.
+//Info lines are written to stream like InfoS() and are dropped if the stream is disabled, use a negative stream for no stream
+func NewStdLogger(level Level, stream int) *log.Logger {
+	if stream > 63 {
+		stream = 63
+	}
+	if stream < 0 {
+		stream = noStream
+	}
+
+	return log.New(&lineWriter{logType: uint64(level), stream: stream}, "", 0)
+}
+
+//RedirectStdLog sends the output of the standard log package (log.Println...) to xlogging as level entries.
+//By default the standard logger writes to the log file without a level prefix
+func RedirectStdLog(level Level) {
+	w := &lineWriter{logType: uint64(level), stream: noStream}
+
+	settingsMu.Lock()
+	previous := stdLogWriter
+	stdLogWriter = w
+	settingsMu.Unlock()
+
+	if previous != nil {
+		previous.Flush()
+	}
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(w)
+}
+
+//flushStdLog writes the incomplete last line of the redirected standard logger
+func flushStdLog() {
+	settingsMu.RLock()
+	w := stdLogWriter
+	settingsMu.RUnlock()
+
+	if w != nil {
+		w.Flush()
+	}
+}
+
+//Write implements io.Writer. Incomplete lines are kept until the rest of the line is written
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.buf.Write(p)
+	var lines []string
+	for {
+		data := w.buf.Bytes()
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, string(data[:i]))
+		w.buf.Next(i + 1)
+	}
+	w.mu.Unlock()
+
+	for _, line := range lines {
+		w.writeLine(strings.TrimSuffix(line, "\r"))
+	}
+
+	return len(p), nil
+}
+
+//Flush writes the incomplete last line, if any, as an entry
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	line := w.buf.String()
+	w.buf.Reset()
+	w.mu.Unlock()
+
+	if line != "" {
+		w.writeLine(strings.TrimSuffix(line, "\r"))
+	}
+	return nil
+}
+
+//Close implements io.Closer, see Flush
+func (w *lineWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *lineWriter) writeLine(line string) {
+	if !canLog(w.logType) {
+		return
+	}
+	if w.logType == logInfo && w.stream != noStream && !isStreamEnabled(byte(w.stream)) {
+		return
+	}
+
+	e, needsCaller := makeEntry(w.logType, w.stream, line)
+	if needsCaller {
+		e.pc, e.File, e.Line, e.callerOK = callerOutside()
+	}
+
+	writeEntry(e)
+}
+
+//callerOutside returns the first caller that is not in the log, fmt or xlogging packages
+func callerOutside() (pc uintptr, file string, line int, ok bool) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame.Function) {
+			return frame.PC, frame.File, frame.Line, frame.File != ""
+		}
+		if !more {
+			break
+		}
+	}
+
+	return 0, "", 0, false
+}
+
+func isLoggingFrame(function string) bool {
+	return strings.HasPrefix(function, "log.") ||
+		strings.HasPrefix(function, "fmt.") ||
+		strings.HasPrefix(function, packagePath+".")
+}
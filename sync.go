@@ -0,0 +1,162 @@
+package xlogging
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//SyncPolicy when the log file is committed to disk
+type SyncPolicy int
+
+//Sync policies
+const (
+	//SyncNever leaves it to the OS
+	SyncNever SyncPolicy = iota
+	//SyncEveryEntry syncs after every entry, NoFmt() included
+	SyncEveryEntry
+	//SyncWarnError syncs after Warn and Error entries
+	SyncWarnError
+	//SyncInterval syncs every interval if something was written
+	SyncInterval
+)
+
+//defaultSyncInterval interval of SyncInterval if none is set
+const defaultSyncInterval = time.Second
+
+//syncPolicy when the log file is synced
+var syncPolicy = SyncNever
+
+//syncInterval interval of SyncInterval
+var syncInterval = defaultSyncInterval
+
+//syncPolicyNames names used in Config.Sync
+var syncPolicyNames = map[string]SyncPolicy{
+	"never":     SyncNever,
+	"entry":     SyncEveryEntry,
+	"warnerror": SyncWarnError,
+	"interval":  SyncInterval,
+}
+
+//SyncStats counts the syncs of the log file and their latency
+type SyncStats struct {
+	Count  int64
+	Errors int64
+	//TotalTime time spent in all syncs. TotalTime / Count is the average latency
+	TotalTime time.Duration
+	MaxTime   time.Duration
+	LastTime  time.Duration
+}
+
+var syncMu sync.Mutex
+var syncStats SyncStats
+
+//syncTickerRunning true while the SyncInterval goroutine runs, guarded by syncMu
+var syncTickerRunning = false
+
+//syncDirty 1 if entries were written since the last interval sync
+var syncDirty int32
+
+//SetSyncPolicy sets when the log file is committed to disk. interval is used by SyncInterval, 0 uses 1 second
+func SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	settingsMu.Lock()
+	syncPolicy = policy
+	syncInterval = interval
+	settingsMu.Unlock()
+}
+
+//GetSyncStats returns the sync counters since start
+func GetSyncStats() SyncStats {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	return syncStats
+}
+
+//syncAfterEntry syncs the log file if the policy asks for it
+func syncAfterEntry(e *entry) {
+	if !isLogFileAttached() {
+		return
+	}
+
+	settingsMu.RLock()
+	policy, interval := syncPolicy, syncInterval
+	settingsMu.RUnlock()
+
+	switch policy {
+	case SyncEveryEntry:
+		syncLogFile()
+	case SyncWarnError:
+		if e.Level == LevelWarn || e.Level == LevelError {
+			syncLogFile()
+		}
+	case SyncInterval:
+		atomic.StoreInt32(&syncDirty, 1)
+		startSyncTicker(interval)
+	}
+}
+
+//syncLogFile syncs the attached log file and updates the stats
+func syncLogFile() error {
+	//ReopenLogFile closes the previous file holding attachedMu, so it is not closed while syncing
+	attachedMu.RLock()
+	f := attached.f
+	if f == nil {
+		attachedMu.RUnlock()
+		return nil
+	}
+
+	start := time.Now()
+	err := f.Sync()
+	elapsed := time.Since(start)
+	attachedMu.RUnlock()
+
+	syncMu.Lock()
+	syncStats.Count++
+	if err != nil {
+		syncStats.Errors++
+	}
+	syncStats.TotalTime += elapsed
+	syncStats.LastTime = elapsed
+	if elapsed > syncStats.MaxTime {
+		syncStats.MaxTime = elapsed
+	}
+	syncMu.Unlock()
+
+	return err
+}
+
+//startSyncTicker starts the SyncInterval goroutine if it is not running.
+//It stops when the policy or interval changes, the next entry starts it again
+func startSyncTicker(interval time.Duration) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+
+	if syncTickerRunning {
+		return
+	}
+	syncTickerRunning = true
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if atomic.SwapInt32(&syncDirty, 0) == 1 {
+				syncLogFile()
+			}
+
+			settingsMu.RLock()
+			changed := syncPolicy != SyncInterval || syncInterval != interval
+			settingsMu.RUnlock()
+			if changed {
+				syncMu.Lock()
+				syncTickerRunning = false
+				syncMu.Unlock()
+				return
+			}
+		}
+	}()
+}
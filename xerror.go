@@ -1,5 +1,12 @@
 package xlogging
 
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+)
+
 type stdError struct {
 	s string
 }
@@ -7,3 +14,109 @@ type stdError struct {
 func (e stdError) Error() string {
 	return e.s
 }
+
+//maxErrorStackDepth max frames captured by NewError and WrapError
+const maxErrorStackDepth = 32
+
+//XError is an error with the stack of where it was created, a cause and fields.
+//When passed to Error() or Errorf() the stack of the error is printed instead of the stack of the log call
+type XError struct {
+	message string
+	cause   error
+	fields  []Field
+	pcs     []uintptr
+}
+
+//NewError returns an error that captures the current stack
+func NewError(message string) *XError {
+	return newXError(message, nil)
+}
+
+//WrapError returns an error that wraps cause and captures the current stack.
+//errors.Is, errors.As and errors.Unwrap see cause
+func WrapError(cause error, message string) *XError {
+	return newXError(message, cause)
+}
+
+func newXError(message string, cause error) *XError {
+	pcs := make([]uintptr, maxErrorStackDepth)
+	n := runtime.Callers(3, pcs)
+
+	return &XError{message: message, cause: cause, pcs: pcs[:n]}
+}
+
+//Error implements error. "message: cause"
+func (e *XError) Error() string {
+	if e.cause == nil {
+		return e.message
+	}
+	if e.message == "" {
+		return e.cause.Error()
+	}
+	return e.message + ": " + e.cause.Error()
+}
+
+//Unwrap returns the cause
+func (e *XError) Unwrap() error {
+	return e.cause
+}
+
+//Message returns the message without the cause
+func (e *XError) Message() string {
+	return e.message
+}
+
+//WithField adds a field to the error and returns it
+func (e *XError) WithField(key string, value interface{}) *XError {
+	e.fields = append(e.fields, Field{Key: key, Value: value})
+	return e
+}
+
+//Fields returns the fields of the error
+func (e *XError) Fields() []Field {
+	return e.fields
+}
+
+//Stack returns the stack of where the error was created
+func (e *XError) Stack() string {
+	var strBuffer bytes.Buffer
+	frames := runtime.CallersFrames(e.pcs)
+	for {
+		frame, more := frames.Next()
+		strBuffer.WriteString(frame.Function)
+		strBuffer.WriteString("()\n\t")
+		strBuffer.WriteString(frame.File)
+		strBuffer.WriteString(":")
+		strBuffer.WriteString(strconv.Itoa(frame.Line))
+		strBuffer.WriteString("\n")
+		if !more {
+			break
+		}
+	}
+	return strBuffer.String()
+}
+
+//getErrorDetails returns the stack of the innermost XError in v and the fields of all XErrors in v
+func getErrorDetails(v []interface{}) (stack string, fields []Field) {
+	for i := range v {
+		err, ok := v[i].(error)
+		if !ok {
+			continue
+		}
+
+		var origin *XError
+		for err != nil {
+			if xerr, ok := err.(*XError); ok {
+				origin = xerr
+				fields = append(fields, xerr.fields...)
+			}
+			err = errors.Unwrap(err)
+		}
+
+		if origin != nil && stack == "" {
+			stack = origin.Stack()
+		}
+	}
+
+	return stack, fields
+}
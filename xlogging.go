@@ -24,11 +24,15 @@ package xlogging
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,6 +48,8 @@ const (
 	logError uint64 = 1 << 2
 	//logAll enables all logs when assigned to loggingLevel
 	logAll uint64 = logInfo | logWarn | logError
+	//logNoFmt type of NoFmt() entries. Not part of loggingLevel, NoFmt() is always written
+	logNoFmt uint64 = 1 << 7
 )
 
 //loggingLevel bitFlag that defines which log types are printed
@@ -53,6 +59,7 @@ var loggingLevel = logAll
 var enabledStreams uint64
 
 //Log Prefix (Similar to Log4Net so highlighters can use it)
+//Defaults, can be changed with SetLevelPrefix()
 const (
 	prefixLog       = "LOG::"
 	prefixWarn      = "WARN::"
@@ -91,18 +98,39 @@ var showTime = true
 
 var showLoggerInitLogs = true
 
+//logger writes all log lines. Separate from the standard logger so it can be redirected with RedirectStdLog()
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+//settingsMu guards the log settings (levels, streams, styles, layout, split rules) so a config can be applied atomically
+var settingsMu sync.RWMutex
+
+//configEnvVar environment variable with the path of a json config loaded at startup
+const configEnvVar = "XLOGGING_CONFIG"
+
 func init() {
+	var errConfig error
+	if configPath := os.Getenv(configEnvVar); configPath != "" {
+		errConfig = LoadConfig(configPath)
+	}
+
 	setupLogFlags()
 
 	err := setupFileIO()
 
-	if err != nil {
+	if isReadOnlyError(err) {
+		//Expected for containers with a read-only root, logs go to stderr
+		fmt.Fprintln(os.Stderr, "[LoggerInit] "+err.Error()+". Logging to stderr")
+	} else if err != nil {
 		fmt.Println("[LoggerInit] Error: Failed to setup logFile. " + err.Error())
 		debug.PrintStack()
 		NoFmt("LOGGER SETUP: Log File Failed to attach!")
 	} else if showLoggerInitLogs {
 		NoFmt("LOGGER SETUP")
-		NoFmt("Logger File Path: " + logFilePath)
+		NoFmt("Logger File Path: " + GetLogFilePath())
+	}
+
+	if errConfig != nil {
+		NoFmt("LOGGER SETUP: Config " + os.Getenv(configEnvVar) + " failed to load! " + errConfig.Error())
 	}
 
 	if showLoggerInitLogs {
@@ -124,195 +152,342 @@ func setupLogFlags() {
 			logFlags |= log.LUTC
 		}
 	}
+	logger.SetFlags(logFlags)
 	log.SetFlags(logFlags)
 }
 
-func printLog(logType, style uint64, v ...interface{}) {
-	if checkFlag(style, stPrintStack) {
-		printSpace()
+//Entry is a log line before it is formatted. See Hook
+type Entry struct {
+	Level Level
+	//Stream InfoS stream index, -1 for all other logs
+	Stream int
+	Time   time.Time
+	//File and Line of the caller, empty if the caller is not needed by the style or pattern
+	File    string
+	Line    int
+	Message string
+	Fields  []Field
+}
+
+//entry is a single log line before it is formatted
+type entry struct {
+	Entry
+	style    uint64
+	pc       uintptr
+	callerOK bool
+	//stack printed instead of the current stack if set (see XError)
+	stack string
+}
+
+//Field is a key value pair written after the message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//noStream stream value of entries not written by InfoS/InfoSf
+const noStream = -1
+
+func newEntry(logType uint64, stream int, callDepth int, message string) *entry {
+	e, needsCaller := makeEntry(logType, stream, message)
+	if needsCaller {
+		e.pc, e.File, e.Line, e.callerOK = runtime.Caller(callDepth + 1)
+	}
+
+	return e
+}
+
+//makeEntry returns an entry without caller details and true if the caller is needed
+func makeEntry(logType uint64, stream int, message string) (*entry, bool) {
+	settingsMu.RLock()
+	style := getStyle(logType)
+	needsCaller := getFileNameType(style) > 0 || patternNeedsCaller || isRateLimited() || dedupEnabled || captureFn != nil
+	settingsMu.RUnlock()
+
+	e := &entry{
+		Entry: Entry{
+			Level:   Level(logType),
+			Stream:  stream,
+			Time:    time.Now(),
+			Message: message,
+		},
+		style: style,
 	}
 
-	prefix := []interface{}{getLinePrefix(logType, 3)}
-	finalOut := append(prefix, v...)
-	log.Println(finalOut...)
+	return e, needsCaller
+}
 
-	//fmt.Printf("logFile Attached %v\n", logFileAttached)
-	//fmt.Printf("checkFlag %v\n", checkFlag(style, stLogToTerminal))
-	logToTerminal := logFileAttached && checkFlag(style, stLogToTerminal)
-	if logToTerminal {
-		fmt.Println(finalOut...)
+func printLog(logType uint64, stream int, v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	e := newEntry(logType, stream, 2, msg[:len(msg)-1])
+	e.stack, e.Fields = getErrorDetails(v)
+	writeEntry(e)
+}
+
+func printLogf(logType uint64, stream int, format string, v ...interface{}) {
+	e := newEntry(logType, stream, 2, fmt.Sprintf(format, v...))
+	e.stack, e.Fields = getErrorDetails(v)
+	writeEntry(e)
+}
+
+//writeEntry filters and writes an entry
+func writeEntry(e *entry) {
+	redactEntry(e)
+
+	if !runHooks(e) {
+		atomic.AddInt64(&metricHookDropped, 1)
+		return
 	}
 
-	if checkFlag(style, stPrintStack) {
-		printStack(logToTerminal)
-		printSpace()
+	if !rateLimitAllow(e) {
+		return
 	}
+
+	if !dedupAllow(e) {
+		return
+	}
+
+	if !diskAllow(e) {
+		return
+	}
+
+	outputEntry(e)
 }
 
-func printStack(logToTerminal bool) {
-	byteArray := debug.Stack()
-	n := len(byteArray)
-	s := string(byteArray[:n])
+//outputEntry writes an entry to the log file and terminal
+func outputEntry(e *entry) {
+	if captureEntry(e) {
+		return
+	}
+	countEntry(e)
+
+	stack := ""
+	if checkFlag(e.style, stPrintStack) {
+		stack = getStack(e.stack)
+	}
 
-	log.Println(s)
+	settingsMu.RLock()
+	line := formatEntry(e)
+	usePattern := linePattern != nil
+	settingsMu.RUnlock()
+
+	if !usePattern && stack == "" {
+		logger.Println(line)
+	} else {
+		//One write for the whole entry so lines appended by other processes can not split it
+		io.WriteString(logger.Writer(), getEntryText(line, stack, usePattern))
+	}
 
+	logToTerminal := isLogFileAttached() && checkFlag(e.style, stLogToTerminal)
 	if logToTerminal {
-		fmt.Println(s)
+		fmt.Println(line)
+		if stack != "" {
+			fmt.Println(stack)
+		}
 	}
+
+	syncAfterEntry(e)
 }
 
-func printLogf(logType, style uint64, format string, v ...interface{}) {
-	if checkFlag(style, stPrintStack) {
-		printSpace()
+//getStack returns s, or the current stack if s is empty
+func getStack(s string) string {
+	if s == "" {
+		byteArray := debug.Stack()
+		n := len(byteArray)
+		s = string(byteArray[:n])
 	}
+	return s
+}
 
-	prefix := getLinePrefix(logType, 3)
-	prefix += " " + format + "\n"
-	log.Printf(prefix, v...)
+//getEntryText returns the lines of an entry as written by logger. Stacks are written between empty lines
+func getEntryText(line, stack string, usePattern bool) string {
+	var strBuffer bytes.Buffer
+	lineLogger := log.New(&strBuffer, logger.Prefix(), logger.Flags())
 
-	logToTerminal := logFileAttached && checkFlag(style, stLogToTerminal)
-	if logToTerminal {
-		fmt.Printf(prefix, v...)
+	if stack != "" {
+		strBuffer.WriteString("\n")
 	}
 
-	if checkFlag(style, stPrintStack) {
-		printStack(logToTerminal)
-		printSpace()
+	if usePattern {
+		strBuffer.WriteString(line + "\n")
+	} else {
+		lineLogger.Println(line)
 	}
+
+	if stack != "" {
+		lineLogger.Println(stack)
+		strBuffer.WriteString("\n")
+	}
+
+	return strBuffer.String()
 }
 
 //Info prints using Println format to logInfo style log
 func Info(v ...interface{}) {
 	if canLog(logInfo) {
-		printLog(logInfo, styleInfo, v...)
+		printLog(logInfo, noStream, v...)
 	}
 }
 
 //Infof prints using Printf format to logInfo style log
 func Infof(format string, v ...interface{}) {
 	if canLog(logInfo) {
-		printLogf(logInfo, styleInfo, format, v...)
+		printLogf(logInfo, noStream, format, v...)
 	}
 }
 
 //InfoS prints using Printf format to a separate log stream of logInfo style. This can be enabled or disabled individually
 func InfoS(stream byte, v ...interface{}) {
-	if canLog(logInfo) && checkBit(enabledStreams, stream) {
-		streamIndex := []interface{}{stream, "|"}
-		finalOut := append(streamIndex, v...)
-
-		printLog(logInfo, styleInfo, finalOut)
+	if canLog(logInfo) && isStreamEnabled(stream) {
+		printLog(logInfo, int(stream), v...)
 	}
 }
 
 //InfoSf prints using Println format to a separate log stream of logInfo style. This can be enabled or disabled individually
 func InfoSf(stream byte, format string, v ...interface{}) {
-	if canLog(logInfo) && checkBit(enabledStreams, stream) {
-		streamIndex := []interface{}{stream, "|"}
-		finalOut := append(streamIndex, v...)
+	if canLog(logInfo) && isStreamEnabled(stream) {
+		printLogf(logInfo, int(stream), format, v...)
+	}
+}
 
-		printLogf(logInfo, styleInfo, format, finalOut)
+//InfoSFields prints message and key=value fields to a separate log stream of logInfo style
+func InfoSFields(stream byte, message string, fields ...Field) {
+	if canLog(logInfo) && isStreamEnabled(stream) {
+		e := newEntry(logInfo, int(stream), 1, message)
+		e.Fields = fields
+		writeEntry(e)
 	}
 }
 
 //Warn prints using Println format to logWarn style log
 func Warn(v ...interface{}) {
 	if canLog(logWarn) {
-		printLog(logWarn, styleWarn, v...)
+		printLog(logWarn, noStream, v...)
 	}
 }
 
 //Warnf prints using Printf format to logWarn style log
 func Warnf(format string, v ...interface{}) {
 	if canLog(logWarn) {
-		printLogf(logWarn, styleWarn, format, v...)
+		printLogf(logWarn, noStream, format, v...)
 	}
 }
 
 //Error prints using Println format to logWarn style log
 func Error(v ...interface{}) {
 	if canLog(logError) {
-		printLog(logError, styleError, v...)
+		printLog(logError, noStream, v...)
 	}
 }
 
 //Errorf prints using Printf format to logWarn style log
 func Errorf(format string, v ...interface{}) {
 	if canLog(logError) {
-		printLogf(logError, styleError, format, v...)
+		printLogf(logError, noStream, format, v...)
 	}
 }
 
 //NoFmt logs without any special formatting using Println
 func NoFmt(v ...interface{}) {
-	log.Println(v...)
-	if logFileAttached && logNoFmtToTerminal {
-		fmt.Println(v...)
-	}
+	msg := fmt.Sprintln(v...)
+	printNoFmt(msg[:len(msg)-1])
 }
 
 //NoFmtf logs without any special formatting using Printf
 func NoFmtf(format string, v ...interface{}) {
-	log.Printf(format, v...)
-	if logFileAttached && logNoFmtToTerminal {
-		fmt.Printf(format+"\n", v...)
+	printNoFmt(fmt.Sprintf(format, v...))
+}
+
+func printNoFmt(msg string) {
+	e := newEntry(logNoFmt, noStream, 2, msg)
+	redactEntry(e)
+	if !runHooks(e) {
+		atomic.AddInt64(&metricHookDropped, 1)
+		return
+	}
+
+	if captureEntry(e) {
+		return
+	}
+	countEntry(e)
+
+	msg = getMessage(e, true)
+	logger.Print(msg)
+	if isLogFileAttached() && logNoFmtToTerminal {
+		fmt.Println(msg)
 	}
+
+	syncAfterEntry(e)
 }
 
 func canLog(logLv uint64) bool {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
 	return loggingLevel&logLv == logLv
 }
 
-func printSpace() {
-	var orgFlags = log.Flags()
-	log.SetFlags(0)
-	log.Printf("\n")
-	log.SetFlags(orgFlags)
+func isStreamEnabled(stream byte) bool {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return checkBit(enabledStreams, stream)
 }
 
-func getLinePrefix(logType uint64, sourceDepth int) string {
-
-	var strBuffer bytes.Buffer
-	var style uint64
+//getStyle returns the style of a log type. settingsMu must be held
+func getStyle(logType uint64) uint64 {
 	switch logType {
 	case logInfo:
-		strBuffer.WriteString(prefixLog)
-		style = styleInfo
+		return styleInfo
 	case logWarn:
-		strBuffer.WriteString(prefixWarn)
-		style = styleWarn
+		return styleWarn
 	case logError:
-		strBuffer.WriteString(prefixError)
-		style = styleError
-	default:
-		strBuffer.WriteString(prefixBadFormat)
-	}
-
-	fileNameType := 0
-	if checkFlag(style, stLongFileName) {
-		fileNameType = 2
-	} else if checkFlag(style, stShortFileName) {
-		fileNameType = 1
+		return styleError
 	}
+	return stNone
+}
 
-	if fileNameType > 0 {
-		_, file, line, ok := runtime.Caller(sourceDepth)
+func getLinePrefix(e *entry) string {
+	var strBuffer bytes.Buffer
+	strBuffer.WriteString(getLevelPrefix(uint64(e.Level)))
 
-		if ok {
+	if fileNameType := getFileNameType(e.style); fileNameType > 0 {
+		if e.callerOK {
 			strBuffer.WriteString(" ")
-			if fileNameType == 2 {
-				strBuffer.WriteString(file)
-			} else {
-				strBuffer.WriteString(filepath.Base(file))
-			}
-			strBuffer.WriteString("(")
-			strBuffer.WriteString(strconv.Itoa(line))
-			strBuffer.WriteString(")>>")
+			strBuffer.WriteString(getCallerString(e, fileNameType))
+			strBuffer.WriteString(">>")
 		} else {
 			strBuffer.WriteString("File_Format_Failed>>")
 		}
 	}
 
+	if e.Stream != noStream {
+		strBuffer.WriteString(" ")
+		strBuffer.WriteString(strconv.Itoa(e.Stream))
+		strBuffer.WriteString(" |")
+	}
+
+	return strBuffer.String()
+}
+
+//getFileNameType 0: no file name, 1: short file name, 2: long file name
+func getFileNameType(style uint64) int {
+	if checkFlag(style, stLongFileName) {
+		return 2
+	} else if checkFlag(style, stShortFileName) {
+		return 1
+	}
+	return 0
+}
+
+func getCallerString(e *entry, fileNameType int) string {
+	var strBuffer bytes.Buffer
+	if fileNameType == 2 {
+		strBuffer.WriteString(e.File)
+	} else {
+		strBuffer.WriteString(filepath.Base(e.File))
+	}
+	strBuffer.WriteString("(")
+	strBuffer.WriteString(strconv.Itoa(e.Line))
+	strBuffer.WriteString(")")
 	return strBuffer.String()
 }
 
@@ -322,6 +497,9 @@ func EnableStream(enable bool, stream byte) {
 		stream = 63
 	}
 
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
 	if enable {
 		enabledStreams |= 1 << stream
 	} else {
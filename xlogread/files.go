@@ -0,0 +1,106 @@
+package xlogread
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+//logFilePattern names of xlogging files: Log_1_2_2026.log, Log_1_2_2026_3.log, Log.log.2, Log.log.gz ...
+var logFilePattern = regexp.MustCompile(`\.log(\.\d+)?(\.gz)?$`)
+
+//ListFiles returns the log files of a folder, oldest first. A file path is returned as is
+func ListFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logFiles := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		if file.Mode().IsRegular() && logFilePattern.MatchString(file.Name()) {
+			logFiles = append(logFiles, file)
+		}
+	}
+
+	sort.SliceStable(logFiles, func(i, j int) bool {
+		ti, tj := logFiles[i].ModTime(), logFiles[j].ModTime()
+		if ti.Equal(tj) {
+			return logFiles[i].Name() < logFiles[j].Name()
+		}
+		return ti.Before(tj)
+	})
+
+	paths := make([]string, len(logFiles))
+	for i, file := range logFiles {
+		paths[i] = filepath.Join(path, file.Name())
+	}
+	return paths, nil
+}
+
+//OpenFile opens a log file, gzip files are decompressed
+func OpenFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Ext(path) != ".gz" {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, f: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+//ReadFile calls fn for every record in the file that passes filter. fn returns false to stop.
+//format is the layout of the lines, nil for the default layout
+func ReadFile(path string, loc *time.Location, format *Format, filter *Filter, fn func(r *Record) bool) error {
+	f, err := OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := NewReader(f, path, loc)
+	reader.SetFormat(format)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if filter.Match(record) && !fn(record) {
+			return nil
+		}
+	}
+}
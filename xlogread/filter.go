@@ -0,0 +1,115 @@
+package xlogread
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//Filter selects records. Empty values match all records
+type Filter struct {
+	//Levels INFO, WARN, ERROR, NOFMT
+	Levels []string
+	//Since and Until time range, records without a date are not matched if set
+	Since time.Time
+	Until time.Time
+	//Streams InfoS streams
+	Streams []int
+	//File matched against the base name of the caller file (filepath.Match pattern or sub string)
+	File string
+	//Message matched against the message and continuation lines
+	Message *regexp.Regexp
+}
+
+//Match returns true if the record passes the filter
+func (f *Filter) Match(r *Record) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Levels) > 0 && !containsString(f.Levels, r.Level) {
+		return false
+	}
+
+	if !f.Since.IsZero() && (r.Time.IsZero() || r.Time.Before(f.Since)) {
+		return false
+	}
+	if !f.Until.IsZero() && (r.Time.IsZero() || r.Time.After(f.Until)) {
+		return false
+	}
+
+	if len(f.Streams) > 0 && !containsInt(f.Streams, r.Stream) {
+		return false
+	}
+
+	if f.File != "" {
+		base := filepath.Base(r.File)
+		if r.File == "" {
+			return false
+		}
+		if matched, _ := filepath.Match(f.File, base); !matched && !strings.Contains(r.File, f.File) {
+			return false
+		}
+	}
+
+	if f.Message != nil && !f.Message.MatchString(r.Message) && !f.Message.MatchString(strings.Join(r.Stack, "\n")) {
+		return false
+	}
+
+	return true
+}
+
+//ParseLevels converts a comma separated list (info,warn,error,nofmt) to levels
+func ParseLevels(list string) []string {
+	var levels []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.EqualFold(name, LevelNoFmt) {
+			levels = append(levels, LevelNoFmt)
+		} else {
+			levels = append(levels, normalizeLevel(name))
+		}
+	}
+	return levels
+}
+
+//ParseTime parses a filter time: RFC3339, "2006/01/02 15:04:05", "2006-01-02" or a duration before now ("90m")
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, stdDateLayout, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, &time.ParseError{Layout: time.RFC3339, Value: s, Message: ": expected a date or a duration"}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, n int) bool {
+	for _, v := range values {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package xlogread
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+//defaultFollowInterval how often Follow checks for new lines
+const defaultFollowInterval = time.Millisecond * 250
+
+//digitsPattern digit runs replaced to get the shape of a file name
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+//FollowOptions options of Follow
+type FollowOptions struct {
+	//Loc location of dates without a zone, nil for time.Local
+	Loc *time.Location
+	//Format layout of the lines, nil for the default layout
+	Format *Format
+	//Filter records passed to fn, nil for all
+	Filter *Filter
+	//Interval how often the file is checked. Default 250ms
+	Interval time.Duration
+	//FromStart reads the active file from the start instead of only new lines
+	FromStart bool
+	//Stop ends Follow when closed
+	Stop <-chan struct{}
+}
+
+//follower state of Follow
+type follower struct {
+	path   string
+	isDir  bool
+	opts   FollowOptions
+	fn     func(r *Record) bool
+	f      *os.File
+	offset int64
+	//name path of the followed file
+	name string
+	//seen files of the folder that existed or were followed, never switched to
+	seen   map[string]bool
+	reader *Reader
+	//partial line without a new line yet
+	partial []byte
+	buf     []byte
+}
+
+//Follow calls fn for every new record of the active log file of a folder (or of a file) until opts.Stop is closed or fn returns false.
+//The followed path is reopened when it names a new file (rotation): the rest of the old file is read, then the new file from its start.
+//In a folder the newest file is followed. Follow moves to another file only when a new file appears with a name of the same shape
+//(the same name with other digits: the next date, or the next run of a {pid} pattern). Files that already existed are never
+//switched to, so processes or apps sharing the folder do not make it jump between their files
+func Follow(path string, opts FollowOptions, fn func(r *Record) bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = defaultFollowInterval
+	}
+	if opts.Loc == nil {
+		opts.Loc = time.Local
+	}
+
+	fl := &follower{path: path, isDir: info.IsDir(), opts: opts, fn: fn, buf: make([]byte, 32*1024), seen: map[string]bool{}}
+	defer fl.close()
+
+	name, err := fl.waitActiveFile()
+	if err != nil || name == "" {
+		return err
+	}
+	err = fl.open(name, !opts.FromStart)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		more, err := fl.read()
+		if err != nil || !more {
+			return err
+		}
+
+		select {
+		case <-opts.Stop:
+			fl.flush()
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := fl.checkRotation()
+		if err != nil {
+			return err
+		}
+		if next != "" {
+			//Rest of the old file, then the new one from the start
+			more, err = fl.read()
+			if err != nil || !more {
+				return err
+			}
+			if !fl.flush() {
+				return nil
+			}
+			err = fl.open(next, false)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			//Gone before it was opened, keep the open file
+		}
+	}
+}
+
+//logFiles returns the log files of the folder (not .gz), oldest first
+func (fl *follower) logFiles() ([]string, error) {
+	if !fl.isDir {
+		return []string{fl.path}, nil
+	}
+
+	files, err := ListFiles(fl.path)
+	if err != nil {
+		return nil, err
+	}
+
+	logFiles := files[:0]
+	for _, file := range files {
+		if filepath.Ext(file) != ".gz" {
+			logFiles = append(logFiles, file)
+		}
+	}
+	return logFiles, nil
+}
+
+//waitActiveFile returns the newest log file, waits for one if there is none. Empty if stopped.
+//The other files are marked as seen
+func (fl *follower) waitActiveFile() (string, error) {
+	for {
+		files, err := fl.logFiles()
+		if err != nil {
+			return "", err
+		}
+
+		if len(files) > 0 {
+			for _, file := range files {
+				fl.seen[file] = true
+			}
+			return files[len(files)-1], nil
+		}
+
+		select {
+		case <-fl.opts.Stop:
+			return "", nil
+		case <-time.After(fl.opts.Interval):
+		}
+	}
+}
+
+//open opens a file, at its end if atEnd is set. The previous file is closed
+func (fl *follower) open(name string, atEnd bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	fl.close()
+	fl.f, fl.offset, fl.name = f, 0, name
+	fl.seen[name] = true
+	fl.reader = &Reader{source: name, loc: fl.opts.Loc}
+	fl.reader.SetFormat(fl.opts.Format)
+	if atEnd {
+		fl.offset, err = f.Seek(0, io.SeekEnd)
+	}
+	return err
+}
+
+func (fl *follower) close() {
+	if fl.f != nil {
+		fl.f.Close()
+		fl.f = nil
+	}
+}
+
+//read passes the new complete records to fn. Returns false if fn stopped
+func (fl *follower) read() (bool, error) {
+	if fl.f == nil {
+		return true, nil
+	}
+
+	//Truncated (copytruncate)
+	if info, err := fl.f.Stat(); err == nil && info.Size() < fl.offset {
+		fl.offset, fl.partial = 0, nil
+		fl.f.Seek(0, io.SeekStart)
+	}
+
+	gotData := false
+	for {
+		n, err := fl.f.Read(fl.buf)
+		if n > 0 {
+			gotData = true
+			fl.offset += int64(n)
+			fl.partial = append(fl.partial, fl.buf[:n]...)
+			if !fl.addLines() {
+				return false, nil
+			}
+		}
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	//Nothing new, the pending record is complete
+	if !gotData && len(fl.partial) == 0 {
+		if record := fl.reader.Flush(); record != nil {
+			return fl.emit(record), nil
+		}
+	}
+	return true, nil
+}
+
+//addLines passes the complete lines in partial to the reader
+func (fl *follower) addLines() bool {
+	for {
+		i := bytes.IndexByte(fl.partial, '\n')
+		if i < 0 {
+			return true
+		}
+		line := string(fl.partial[:i])
+		fl.partial = fl.partial[i+1:]
+		if record := fl.reader.AddLine(line); record != nil && !fl.emit(record) {
+			return false
+		}
+	}
+}
+
+//flush passes the partial line and pending record to fn
+func (fl *follower) flush() bool {
+	if fl.reader == nil {
+		return true
+	}
+
+	if len(fl.partial) > 0 {
+		line := string(fl.partial)
+		fl.partial = nil
+		if record := fl.reader.AddLine(line); record != nil && !fl.emit(record) {
+			return false
+		}
+	}
+
+	if record := fl.reader.Flush(); record != nil {
+		return fl.emit(record)
+	}
+	return true
+}
+
+func (fl *follower) emit(record *Record) bool {
+	if !fl.opts.Filter.Match(record) {
+		return true
+	}
+	return fl.fn(record)
+}
+
+//checkRotation returns the file to read next, empty to keep reading the open file.
+//It is the followed path if it names a new file, or a new file of the folder with the same name shape
+func (fl *follower) checkRotation() (string, error) {
+	current, err := fl.f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(fl.name); err == nil && !os.SameFile(current, info) {
+		return fl.name, nil
+	}
+	//A missing path is renamed or removed, its new file is not created yet
+
+	if !fl.isDir {
+		return "", nil
+	}
+
+	files, err := fl.logFiles()
+	if err != nil {
+		return "", err
+	}
+
+	shape := getNameShape(fl.name)
+	next := ""
+	for _, file := range files {
+		if fl.seen[file] {
+			continue
+		}
+		fl.seen[file] = true
+		if getNameShape(file) == shape {
+			//Oldest first, the last one is the newest
+			next = file
+		}
+	}
+	return next, nil
+}
+
+//getNameShape returns the file name with digit runs replaced: Log_17_10_2026.log -> Log_#_#_#.log
+func getNameShape(path string) string {
+	return digitsPattern.ReplaceAllString(filepath.Base(path), "#")
+}
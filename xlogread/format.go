@@ -0,0 +1,361 @@
+package xlogread
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Format line layout of logs written with a pattern, level names or prefixes other than the defaults.
+//The json keys match xlogging.Config so a Format can be read from the config file of the app, see ReadFormat()
+type Format struct {
+	//Pattern line layout, see xlogging.SetPattern(). Empty for the default layout
+	Pattern string `json:"pattern"`
+	//LevelNames names written by %level, keyed by "info", "warn" or "error"
+	LevelNames map[string]string `json:"levelNames"`
+	//Prefixes prefixes written for each log type, keyed by "info", "warn" or "error"
+	Prefixes map[string]string `json:"prefixes"`
+
+	prefixes []levelText
+	names    []levelText
+	//line compiled Pattern, nil for the default layout
+	line   *regexp.Regexp
+	fields []patternField
+}
+
+//levelText text written for a level
+type levelText struct {
+	text  string
+	level string
+}
+
+//patternField value captured by a pattern token
+type patternField struct {
+	kind string
+	//layout date layout of "date"
+	layout string
+}
+
+//defaultFormat the default xlogging layout
+var defaultFormat = mustNewFormat(&Format{})
+
+//Named date formats of %date{name}, as written by xlogging
+var dateFormats = map[string]string{
+	"":        "2006/01/02 15:04:05",
+	"iso":     "2006-01-02T15:04:05.000Z07:00",
+	"iso8601": "2006-01-02T15:04:05.000Z07:00",
+	"rfc3339": time.RFC3339,
+	"time":    "15:04:05.000",
+	"unix":    "unix",
+}
+
+//patternKinds pattern keywords and the value they write
+var patternKinds = map[string]string{
+	"date":    "date",
+	"d":       "date",
+	"level":   "level",
+	"p":       "level",
+	"prefix":  "prefix",
+	"stream":  "stream",
+	"caller":  "caller",
+	"file":    "file",
+	"line":    "line",
+	"L":       "line",
+	"func":    "func",
+	"M":       "func",
+	"message": "message",
+	"msg":     "message",
+	"m":       "message",
+	"pid":     "pid",
+	"newline": "newline",
+	"n":       "newline",
+	"fields":  "fields",
+}
+
+var callerValuePattern = regexp.MustCompile(`^(.+)\((\d+)\)$`)
+
+//NewFormat checks and compiles a Format
+func NewFormat(format *Format) (*Format, error) {
+	f := &Format{Pattern: format.Pattern, LevelNames: format.LevelNames, Prefixes: format.Prefixes}
+
+	var err error
+	f.prefixes, err = getLevelTexts(map[string]string{"info": "LOG::", "warn": "WARN::", "error": "ERROR! "}, f.Prefixes)
+	if err != nil {
+		return nil, err
+	}
+	f.names, err = getLevelTexts(map[string]string{"info": LevelInfo, "warn": LevelWarn, "error": LevelError}, f.LevelNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Pattern != "" {
+		err = f.compilePattern()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func mustNewFormat(format *Format) *Format {
+	f, err := NewFormat(format)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+//ReadFormat reads the Format from a xlogging json config file. Other keys are ignored
+func ReadFormat(configPath string) (*Format, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var format Format
+	err = json.Unmarshal(data, &format)
+	if err != nil {
+		return nil, errors.New("format: " + configPath + ": " + err.Error())
+	}
+	return NewFormat(&format)
+}
+
+//getLevelTexts returns the texts of the levels, longest first so a text is not taken for a shorter one it starts with
+func getLevelTexts(defaults map[string]string, texts map[string]string) ([]levelText, error) {
+	merged := make(map[string]string, len(defaults))
+	for name, text := range defaults {
+		merged[name] = text
+	}
+	for name, text := range texts {
+		if _, ok := defaults[strings.ToLower(name)]; !ok {
+			return nil, errors.New("format: unknown level '" + name + "'")
+		}
+		merged[strings.ToLower(name)] = text
+	}
+
+	var result []levelText
+	for name, text := range merged {
+		text = strings.TrimSpace(text)
+		if text != "" {
+			result = append(result, levelText{text, normalizeLevel(name)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].text) != len(result[j].text) {
+			return len(result[i].text) > len(result[j].text)
+		}
+		return result[i].text < result[j].text
+	})
+	return result, nil
+}
+
+//compilePattern builds the regular expression of the first line written by Pattern
+func (f *Format) compilePattern() error {
+	var expr bytes.Buffer
+	expr.WriteString("^")
+
+	pattern := f.Pattern
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' {
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+
+		i++
+		if i >= len(pattern) {
+			return errors.New("format: pattern ends with '%'")
+		}
+		if pattern[i] == '%' {
+			expr.WriteString("%")
+			continue
+		}
+
+		//Width, the value is padded with spaces
+		padded := false
+		if pattern[i] == '-' {
+			i++
+		}
+		for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+			padded = true
+			i++
+		}
+
+		start := i
+		for i < len(pattern) && isPatternLetter(pattern[i]) {
+			i++
+		}
+		name := pattern[start:i]
+		kind, ok := patternKinds[name]
+		if !ok {
+			return errors.New("format: unknown pattern keyword '%" + name + "'")
+		}
+
+		arg := ""
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return errors.New("format: pattern keyword '%" + name + "' has no closing '}'")
+			}
+			arg = pattern[i+1 : i+end]
+			i += end
+		} else {
+			i--
+		}
+
+		if kind == "newline" {
+			//The next lines are read as continuation lines
+			break
+		}
+
+		field := patternField{kind: kind}
+		var valueExpr string
+		switch kind {
+		case "date":
+			field.layout = arg
+			if layout, ok := dateFormats[arg]; ok {
+				field.layout = layout
+			}
+			valueExpr = getDateExpr(field.layout)
+		case "level":
+			valueExpr = getLevelTextsExpr(f.names)
+		case "prefix":
+			valueExpr = getLevelTextsExpr(f.prefixes)
+		case "stream":
+			valueExpr = `\d+|-`
+		case "caller":
+			valueExpr = `.+?\(\d+\)|File_Format_Failed`
+		case "line", "pid":
+			valueExpr = `\d+`
+		case "file", "func":
+			valueExpr = `\S+`
+		default:
+			//message and fields
+			valueExpr = `.*?`
+		}
+
+		if padded {
+			expr.WriteString(" *")
+		}
+		if kind == "pid" {
+			expr.WriteString("(?:" + valueExpr + ")")
+		} else {
+			expr.WriteString("(" + valueExpr + ")")
+			f.fields = append(f.fields, field)
+		}
+		if padded || kind == "prefix" {
+			//The default prefix "ERROR! " ends with a space
+			expr.WriteString(" *")
+		}
+	}
+	expr.WriteString("$")
+
+	var err error
+	f.line, err = regexp.Compile(expr.String())
+	if err != nil {
+		return errors.New("format: pattern: " + err.Error())
+	}
+	return nil
+}
+
+func isPatternLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+//getDateExpr returns an expression matching dates of a go layout, one run of non spaces for every word of the layout
+func getDateExpr(layout string) string {
+	if layout == "unix" {
+		return `\d+`
+	}
+
+	words := strings.Fields(layout)
+	if len(words) == 0 {
+		return ""
+	}
+	return `\S+` + strings.Repeat(` +\S+`, len(words)-1)
+}
+
+func getLevelTextsExpr(texts []levelText) string {
+	quoted := make([]string, len(texts))
+	for i, t := range texts {
+		quoted[i] = regexp.QuoteMeta(t.text)
+	}
+	return strings.Join(quoted, "|")
+}
+
+//findLevel returns the level of a level name or prefix
+func findLevel(texts []levelText, s string) string {
+	for _, t := range texts {
+		if t.text == s {
+			return t.level
+		}
+	}
+	return LevelNoFmt
+}
+
+//parsePatternLine parses a line written by the pattern. Returns nil if it does not match
+func (f *Format) parsePatternLine(line string, loc *time.Location) *Record {
+	m := f.line.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	//A pattern without %level or %prefix is only used for Info, Warn and Error
+	record := &Record{Level: LevelInfo, Stream: -1, Lines: []string{line}}
+	var message, fields string
+	for i, field := range f.fields {
+		value := strings.TrimSpace(m[i+1])
+		switch field.kind {
+		case "date":
+			if field.layout == "unix" {
+				seconds, _ := strconv.ParseInt(value, 10, 64)
+				record.Time = time.Unix(seconds, 0)
+				continue
+			}
+			t, err := time.ParseInLocation(field.layout, value, loc)
+			if err != nil {
+				return nil
+			}
+			record.Time = t
+		case "level":
+			record.Level = findLevel(f.names, value)
+		case "prefix":
+			record.Level = findLevel(f.prefixes, value)
+		case "stream":
+			if value != "-" {
+				record.Stream, _ = strconv.Atoi(value)
+			}
+		case "caller":
+			if c := callerValuePattern.FindStringSubmatch(value); c != nil {
+				record.File = c[1]
+				record.Line, _ = strconv.Atoi(c[2])
+			}
+		case "file":
+			if value != "?" {
+				record.File = value
+			}
+		case "line":
+			record.Line, _ = strconv.Atoi(value)
+		case "message":
+			message = m[i+1]
+		case "fields":
+			fields = value
+		}
+	}
+
+	record.Message = message
+	if fields != "" {
+		if record.Message != "" {
+			record.Message += " "
+		}
+		record.Message += fields
+	}
+	return record
+}
@@ -0,0 +1,213 @@
+package xlogread
+
+import (
+	"container/heap"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//MergeSource a folder or file read by Merge
+type MergeSource struct {
+	Path string
+	//Tag name of the origin passed with each record. Default: the folder name, or its parent for "logs" folders
+	Tag string
+	//Loc location of dates without a zone, nil for time.Local (time.UTC for logs written with useUTC)
+	Loc *time.Location
+	//Format layout of the lines, nil for the default layout
+	Format *Format
+}
+
+//mergeReader reads the records of one source in order
+type mergeReader struct {
+	index  int
+	tag    string
+	loc    *time.Location
+	format *Format
+	files  []string
+	f      io.ReadCloser
+	reader *Reader
+	//record next record, key its time or the time of the record before it if it has no date
+	record *Record
+	key    time.Time
+}
+
+//next reads the next record, record is nil at the end of the source
+func (m *mergeReader) next() error {
+	for len(m.files) > 0 || m.reader != nil {
+		if m.reader == nil {
+			f, err := OpenFile(m.files[0])
+			if err != nil {
+				return err
+			}
+			m.f = f
+			m.reader = NewReader(f, m.files[0], m.loc)
+			m.reader.SetFormat(m.format)
+			m.files = m.files[1:]
+		}
+
+		record, err := m.reader.Next()
+		if err == io.EOF {
+			m.f.Close()
+			m.f, m.reader = nil, nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		m.record = record
+		if !record.Time.IsZero() {
+			m.key = record.Time
+		}
+		return nil
+	}
+
+	m.record = nil
+	return nil
+}
+
+func (m *mergeReader) close() {
+	if m.f != nil {
+		m.f.Close()
+	}
+}
+
+//mergeHeap orders the readers by the time of their next record, then by source order
+type mergeHeap []*mergeReader
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].key.Equal(h[j].key) {
+		return h[i].index < h[j].index
+	}
+	return h[i].key.Before(h[j].key)
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeReader)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
+
+//Merge calls fn with the records of all sources in time order and the tag of their source. fn returns false to stop.
+//The files of a folder are read in the order of their first record.
+//Records without a date (lines not written by xlogging) keep their place after the record before them in their file
+func Merge(sources []MergeSource, filter *Filter, fn func(r *Record, tag string) bool) error {
+	readers := make([]*mergeReader, 0, len(sources))
+	defer func() {
+		for _, m := range readers {
+			m.close()
+		}
+	}()
+
+	h := &mergeHeap{}
+	for i, source := range sources {
+		files, err := ListFiles(source.Path)
+		if err != nil {
+			return err
+		}
+		err = sortFilesByTime(files, source.Loc, source.Format)
+		if err != nil {
+			return err
+		}
+
+		m := &mergeReader{index: i, tag: source.Tag, loc: source.Loc, format: source.Format, files: files}
+		if m.tag == "" {
+			m.tag = DefaultTag(source.Path)
+		}
+		readers = append(readers, m)
+
+		err = m.next()
+		if err != nil {
+			return err
+		}
+		if m.record != nil {
+			heap.Push(h, m)
+		}
+	}
+
+	for h.Len() > 0 {
+		m := (*h)[0]
+		if filter.Match(m.record) && !fn(m.record, m.tag) {
+			return nil
+		}
+
+		err := m.next()
+		if err != nil {
+			return err
+		}
+		if m.record == nil {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+
+	return nil
+}
+
+//sortFilesByTime orders files by the time of their first record. ListFiles orders by modification time, which copied folders lose.
+//A file without a dated record stays after the file before it
+func sortFilesByTime(files []string, loc *time.Location, format *Format) error {
+	if len(files) < 2 {
+		return nil
+	}
+
+	keys := make(map[string]time.Time, len(files))
+	var key time.Time
+	for _, path := range files {
+		t, err := getFirstRecordTime(path, loc, format)
+		if err != nil {
+			return err
+		}
+		if !t.IsZero() {
+			key = t
+		}
+		keys[path] = key
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return keys[files[i]].Before(keys[files[j]])
+	})
+	return nil
+}
+
+//getFirstRecordTime returns the time of the first dated record of a file, zero if there is none
+func getFirstRecordTime(path string, loc *time.Location, format *Format) (time.Time, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	reader := NewReader(f, path, loc)
+	reader.SetFormat(format)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return time.Time{}, nil
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !record.Time.IsZero() {
+			return record.Time, nil
+		}
+	}
+}
+
+//DefaultTag returns the tag of a source path: host1/logs -> host1, host2.log -> host2.log
+func DefaultTag(path string) string {
+	path = filepath.Clean(path)
+	tag := filepath.Base(path)
+	if tag == "logs" {
+		if parent := filepath.Base(filepath.Dir(path)); parent != "." && parent != string(filepath.Separator) {
+			return parent
+		}
+	}
+	return tag
+}
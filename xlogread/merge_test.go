@@ -0,0 +1,91 @@
+package xlogread
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	dir := tempDir(t)
+	host1 := filepath.Join(dir, "host1", "logs")
+	host2 := filepath.Join(dir, "host2", "logs")
+	os.MkdirAll(host1, 0755)
+	os.MkdirAll(host2, 0755)
+
+	now := time.Now()
+	writeFile(t, filepath.Join(host1, "Log_1.log"), "2026/10/19 06:00:01 LOG:: a1\n2026/10/19 06:00:05 LOG:: a2\nundated after a2\n", now.Add(-time.Hour))
+	writeFile(t, filepath.Join(host1, "Log_2.log"), "2026/10/19 06:00:09 LOG:: a3\n", now)
+	writeFile(t, filepath.Join(host2, "Log_1.log"), "2026/10/19 06:00:03 LOG:: b1\n2026/10/19 06:00:05 LOG:: b2\n2026/10/19 06:00:07 LOG:: b3\n", now)
+	//Written in UTC+1, 06:00:04 UTC
+	writeFile(t, filepath.Join(dir, "db.log"), "2026/10/19 07:00:04 WARN:: c1\n", now)
+
+	sources := []MergeSource{
+		{Path: host1, Loc: time.UTC},
+		{Path: host2, Loc: time.UTC},
+		{Path: filepath.Join(dir, "db.log"), Tag: "db", Loc: time.FixedZone("UTC+1", 3600)},
+	}
+
+	var got []string
+	err := Merge(sources, nil, func(r *Record, tag string) bool {
+		got = append(got, tag+" "+r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"host1 a1", "host2 b1", "db c1", "host1 a2", "host2 b2", "host2 b3", "host1 a3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %q, want %q", got, want)
+	}
+
+	//Stop and filter
+	got = nil
+	err = Merge(sources, &Filter{Levels: ParseLevels("warn")}, func(r *Record, tag string) bool {
+		got = append(got, tag+" "+r.Message)
+		return false
+	})
+	if err != nil || !reflect.DeepEqual(got, []string{"db c1"}) {
+		t.Errorf("Merge filtered = %q, %v", got, err)
+	}
+}
+
+func TestDefaultTag(t *testing.T) {
+	tests := map[string]string{
+		"host1/logs":     "host1",
+		"host1/logs/":    "host1",
+		"logs":           "logs",
+		"backup/db.log":  "db.log",
+		"/var/app/logs2": "logs2",
+	}
+	for path, want := range tests {
+		if got := DefaultTag(filepath.FromSlash(path)); got != want {
+			t.Errorf("DefaultTag(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMergeSameModTime(t *testing.T) {
+	dir := tempDir(t)
+	//Copied folders reset the modification times, the name order is not the time order
+	now := time.Now()
+	writeFile(t, filepath.Join(dir, "Log_10_10_2026.log"), "2026/10/10 08:00:00 LOG:: oct10\n", now)
+	writeFile(t, filepath.Join(dir, "Log_9_10_2026.log"), "2026/10/09 08:00:00 LOG:: oct9\n2026/10/09 23:00:00 LOG:: oct9 late\n", now)
+	writeFile(t, filepath.Join(dir, "Log_9_10_2026_1.log"), "undated\n2026/10/09 06:00:00 LOG:: oct9 early\n", now)
+	writeFile(t, filepath.Join(dir, "Log_8_10_2026.log"), "no date\n", now)
+
+	var got []string
+	err := Merge([]MergeSource{{Path: dir, Loc: time.UTC}}, nil, func(r *Record, tag string) bool {
+		got = append(got, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"undated", "oct9 early", "oct9", "oct9 late", "oct10", "no date"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %q, want %q", got, want)
+	}
+}
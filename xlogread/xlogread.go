@@ -0,0 +1,291 @@
+//Package xlogread reads xlogging log files back into records.
+//It understands the default line layout ("2017/09/27 10:00:00 WARN:: file.go(12)>> 3 | message"),
+//multi-line stacks, lines starting with an ISO date and json lines.
+//Logs written with a pattern, level names or prefixes other than the defaults are read with a Format.
+package xlogread
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Record levels
+const (
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+	//LevelNoFmt lines written with NoFmt() and lines that are not from xlogging
+	LevelNoFmt = "NOFMT"
+)
+
+//Layout of the default date written by xlogging
+const stdDateLayout = "2006/01/02 15:04:05"
+
+//maxLineSize longest line that can be read
+const maxLineSize = 1024 * 1024
+
+var callerPattern = regexp.MustCompile(`^(.+)\((\d+)\)>>`)
+var streamPattern = regexp.MustCompile(`^(\d{1,2}) \|( |$)`)
+
+//stackStartPattern first line of a stack, written with a date by xlogging. "goroutine 6 [running]:" or "pkg.Func()"
+var stackStartPattern = regexp.MustCompile(`^(goroutine \d+ \[.*\]:|[\w./*()\-]+\(\))$`)
+
+//Record is one log entry and its continuation lines
+type Record struct {
+	//Source file the record was read from
+	Source string `json:"source,omitempty"`
+	//Time zero if the line has no date
+	Time  time.Time `json:"time,omitempty"`
+	Level string    `json:"level"`
+	//Stream InfoS stream, -1 if none
+	Stream  int    `json:"stream"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+	//Stack continuation lines (stacks, multi-line messages)
+	Stack []string `json:"stack,omitempty"`
+	//Lines raw lines of the record
+	Lines []string `json:"-"`
+}
+
+//Text returns the raw lines of the record
+func (r *Record) Text() string {
+	return strings.Join(r.Lines, "\n")
+}
+
+//Reader reads records from xlogging output
+type Reader struct {
+	scanner *bufio.Scanner
+	source  string
+	loc     *time.Location
+	format  *Format
+	pending *Record
+}
+
+//NewReader returns a Reader. Dates without a zone are read in loc, nil for time.Local (use time.UTC for logs written with useUTC)
+func NewReader(r io.Reader, source string, loc *time.Location) *Reader {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner, source: source, loc: loc, format: defaultFormat}
+}
+
+//SetFormat sets the layout of the lines, nil for the default layout
+func (r *Reader) SetFormat(format *Format) {
+	if format == nil {
+		format = defaultFormat
+	}
+	r.format = format
+}
+
+//Next returns the next record or io.EOF.
+//A record is returned when the line after it starts a new record or the input ends
+func (r *Reader) Next() (*Record, error) {
+	for r.scanner.Scan() {
+		if record := r.AddLine(r.scanner.Text()); record != nil {
+			return record, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if record := r.Flush(); record != nil {
+		return record, nil
+	}
+	return nil, io.EOF
+}
+
+//AddLine adds one line and returns the previous record if the line starts a new one
+func (r *Reader) AddLine(line string) *Record {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		//Space written around stacks
+		return nil
+	}
+
+	record := r.format.ParseLine(line, r.loc)
+	if record == nil || (r.pending != nil && r.pending.Level != LevelNoFmt && isStackStart(record)) {
+		//Continuation of the pending record
+		if r.pending == nil {
+			r.pending = &Record{Source: r.source, Level: LevelNoFmt, Stream: -1, Message: line}
+			r.pending.Lines = []string{line}
+			return nil
+		}
+		stackLine := line
+		if record != nil {
+			stackLine = record.Message
+		}
+		r.pending.Stack = append(r.pending.Stack, stackLine)
+		r.pending.Lines = append(r.pending.Lines, line)
+		return nil
+	}
+
+	record.Source = r.source
+	previous := r.pending
+	r.pending = record
+	return previous
+}
+
+//Flush returns the pending record, nil if there is none
+func (r *Reader) Flush() *Record {
+	record := r.pending
+	r.pending = nil
+	return record
+}
+
+func isStackStart(record *Record) bool {
+	return record.Level == LevelNoFmt && stackStartPattern.MatchString(record.Message)
+}
+
+//ParseLine parses a line of the default layout that starts a record. Returns nil for continuation lines
+func ParseLine(line string, loc *time.Location) *Record {
+	return defaultFormat.ParseLine(line, loc)
+}
+
+//ParseLine parses a line that starts a record. Returns nil for continuation lines.
+//Lines that do not match the pattern are read as lines of the default layout (NoFmt lines, stacks, std log lines)
+func (f *Format) ParseLine(line string, loc *time.Location) *Record {
+	if f.line != nil {
+		if record := f.parsePatternLine(line, loc); record != nil {
+			return record
+		}
+	}
+
+	if strings.HasPrefix(line, "{") {
+		if record := parseJSONLine(line); record != nil {
+			return record
+		}
+	}
+
+	record := &Record{Level: LevelNoFmt, Stream: -1, Lines: []string{line}}
+
+	rest := line
+	if t, n, ok := parseDate(line, loc); ok {
+		record.Time = t
+		rest = line[n:]
+		rest = strings.TrimPrefix(rest, " ")
+	}
+
+	level := ""
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(rest, p.text) {
+			level = p.level
+			rest = strings.TrimLeft(rest[len(p.text):], " ")
+			break
+		}
+	}
+
+	if level == "" {
+		if record.Time.IsZero() {
+			return nil
+		}
+		record.Message = rest
+		return record
+	}
+	record.Level = level
+
+	if strings.HasPrefix(rest, "File_Format_Failed>>") {
+		rest = strings.TrimPrefix(rest[len("File_Format_Failed>>"):], " ")
+	} else if m := callerPattern.FindStringSubmatch(rest); m != nil {
+		record.File = m[1]
+		record.Line, _ = strconv.Atoi(m[2])
+		rest = strings.TrimPrefix(rest[len(m[0]):], " ")
+	}
+
+	if m := streamPattern.FindStringSubmatch(rest); m != nil {
+		record.Stream, _ = strconv.Atoi(m[1])
+		rest = rest[len(m[0]):]
+	}
+
+	record.Message = rest
+	return record
+}
+
+//parseDate parses the date at the start of line. Returns the length of the date
+func parseDate(line string, loc *time.Location) (time.Time, int, bool) {
+	if len(line) >= len(stdDateLayout) && line[4] == '/' {
+		t, err := time.ParseInLocation(stdDateLayout, line[:len(stdDateLayout)], loc)
+		if err == nil {
+			return t, len(stdDateLayout), true
+		}
+	}
+
+	//ISO date written by a pattern (%date{iso})
+	if len(line) >= 19 && line[4] == '-' && line[10] == 'T' {
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			end = len(line)
+		}
+		t, err := time.ParseInLocation(time.RFC3339Nano, line[:end], loc)
+		if err == nil {
+			return t, end, true
+		}
+	}
+
+	return time.Time{}, 0, false
+}
+
+//parseJSONLine reads a json line with keys like time, level, msg, stream, file, line
+func parseJSONLine(line string) *Record {
+	var values map[string]interface{}
+	if json.Unmarshal([]byte(line), &values) != nil {
+		return nil
+	}
+
+	record := &Record{Level: LevelNoFmt, Stream: -1, Lines: []string{line}}
+	for key, value := range values {
+		s, isString := value.(string)
+		n, isNumber := value.(float64)
+		switch strings.ToLower(key) {
+		case "time", "ts", "timestamp":
+			if isString {
+				record.Time, _ = time.Parse(time.RFC3339Nano, s)
+			}
+		case "level", "lvl":
+			if isString {
+				record.Level = normalizeLevel(s)
+			}
+		case "msg", "message":
+			if isString {
+				record.Message = s
+			}
+		case "stream":
+			if isNumber {
+				record.Stream = int(n)
+			}
+		case "file", "caller":
+			if isString {
+				record.File = s
+			}
+		case "line":
+			if isNumber {
+				record.Line = int(n)
+			}
+		}
+	}
+
+	return record
+}
+
+//normalizeLevel converts level names and prefixes to INFO, WARN, ERROR or NOFMT
+func normalizeLevel(s string) string {
+	switch strings.ToUpper(strings.TrimRight(s, ":! ")) {
+	case "INFO", "LOG", "DEBUG":
+		return LevelInfo
+	case "WARN", "WARNING":
+		return LevelWarn
+	case "ERROR", "FATAL":
+		return LevelError
+	}
+	return LevelNoFmt
+}
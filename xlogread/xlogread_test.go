@@ -0,0 +1,283 @@
+package xlogread
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+//Lines written by xlogging with the default settings
+const defaultOutput = `2026/10/19 06:20:57 LOGGER SETUP
+2026/10/19 06:20:57 LOG:: started
+2026/10/19 06:20:57 LOG:: 3 | stream msg
+2026/10/19 06:20:57 WARN:: /tmp/xl/gen/main.go(13)>> disk low
+2026/10/19 06:20:57 ERROR!  main.go(14)>> failed boom
+
+2026/10/19 06:20:57 goroutine 1 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+main.main()
+	/tmp/xl/gen/main.go:14 +0x16f
+
+2026/10/19 06:20:57 raw line
+`
+
+//Lines written with the pattern "%date{iso} [%-5level] %stream %caller - %message" and the level name WARNING
+const patternOutput = `2026-10-19T06:20:59.474Z [INFO ] - main.go(24) - pattern info
+2026-10-19T06:20:59.474Z [INFO ] 3 main.go(25) - pattern stream
+2026-10-19T06:20:59.474Z [INFO ] 3 main.go(26) - pattern fields k="v w"
+2026-10-19T06:20:59.474Z [WARNING] - /tmp/xl/gen/main.go(26) - pattern warn
+2026-10-19T06:20:59.474Z [ERROR] - main.go(27) - pattern error
+
+2026/10/19 06:20:59 goroutine 1 [running]:
+main.main()
+	/tmp/xl/gen/main.go:27 +0x3c9
+
+2026/10/19 06:20:59 raw after pattern
+`
+
+//Lines written with the prefixes "I>" and "E>"
+const prefixOutput = `2026/10/19 06:20:59 I> prefixed info
+2026/10/19 06:20:59 E> main.go(39)>> prefixed error
+2026/10/19 06:20:59 WARN:: prefixed warn
+`
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006/01/02 15:04:05.000", s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want *Record
+	}{
+		{"2026/10/19 06:20:57 LOG:: started",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelInfo, Stream: -1, Message: "started"}},
+		{"2026/10/19 06:20:57 LOG:: 3 | stream msg",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelInfo, Stream: 3, Message: "stream msg"}},
+		{"2026/10/19 06:20:57 WARN:: /tmp/xl/gen/main.go(13)>> disk low",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelWarn, Stream: -1, File: "/tmp/xl/gen/main.go", Line: 13, Message: "disk low"}},
+		{"2026/10/19 06:20:57 ERROR!  main.go(14)>> 12 | failed boom",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelError, Stream: 12, File: "main.go", Line: 14, Message: "failed boom"}},
+		{"2026/10/19 06:20:57 WARN::File_Format_Failed>> no caller",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelWarn, Stream: -1, Message: "no caller"}},
+		{"2026/10/19 06:20:57 raw line",
+			&Record{Time: date("2026/10/19 06:20:57.000"), Level: LevelNoFmt, Stream: -1, Message: "raw line"}},
+		{"LOG:: no date",
+			&Record{Level: LevelInfo, Stream: -1, Message: "no date"}},
+		{"2026-10-19T06:20:59.474Z iso line",
+			&Record{Time: date("2026/10/19 06:20:59.474"), Level: LevelNoFmt, Stream: -1, Message: "iso line"}},
+		{`{"time":"2026-10-19T06:20:59.474Z","level":"warning","msg":"json line","stream":5,"file":"a.go","line":7}`,
+			&Record{Time: date("2026/10/19 06:20:59.474"), Level: LevelWarn, Stream: 5, File: "a.go", Line: 7, Message: "json line"}},
+		{"\t/tmp/xl/gen/main.go:14 +0x16f", nil},
+		{"main.main()", nil},
+	}
+
+	for _, test := range tests {
+		got := ParseLine(test.line, time.UTC)
+		if test.want == nil {
+			if got != nil {
+				t.Errorf("ParseLine(%q) = %+v, want nil", test.line, got)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("ParseLine(%q) = nil", test.line)
+			continue
+		}
+		test.want.Lines = []string{test.line}
+		if !got.Time.Equal(test.want.Time) {
+			t.Errorf("ParseLine(%q).Time = %v, want %v", test.line, got.Time, test.want.Time)
+		}
+		got.Time = test.want.Time
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseLine(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+//readAll returns the records of output read with format
+func readAll(t *testing.T, output string, format *Format) []*Record {
+	reader := NewReader(strings.NewReader(output), "test.log", time.UTC)
+	reader.SetFormat(format)
+
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err != nil {
+			return records
+		}
+		records = append(records, record)
+	}
+}
+
+//summary one line per record: level, stream, message and the number of stack lines
+func summary(records []*Record) []string {
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = strings.Join([]string{r.Level, strconv.Itoa(r.Stream), r.File, strconv.Itoa(r.Line), r.Message, strconv.Itoa(len(r.Stack))}, "|")
+	}
+	return lines
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		format *Format
+		want   []string
+	}{
+		{"default", defaultOutput, nil, []string{
+			"NOFMT|-1||0|LOGGER SETUP|0",
+			"INFO|-1||0|started|0",
+			"INFO|3||0|stream msg|0",
+			"WARN|-1|/tmp/xl/gen/main.go|13|disk low|0",
+			"ERROR|-1|main.go|14|failed boom|5",
+			"NOFMT|-1||0|raw line|0",
+		}},
+		{"pattern", patternOutput, &Format{Pattern: "%date{iso} [%-5level] %stream %caller - %message", LevelNames: map[string]string{"warn": "WARNING"}}, []string{
+			"INFO|-1|main.go|24|pattern info|0",
+			"INFO|3|main.go|25|pattern stream|0",
+			`INFO|3|main.go|26|pattern fields k="v w"|0`,
+			"WARN|-1|/tmp/xl/gen/main.go|26|pattern warn|0",
+			"ERROR|-1|main.go|27|pattern error|3",
+			"NOFMT|-1||0|raw after pattern|0",
+		}},
+		{"prefixes", prefixOutput, &Format{Prefixes: map[string]string{"info": "I>", "error": "E>"}}, []string{
+			"INFO|-1||0|prefixed info|0",
+			"ERROR|-1|main.go|39|prefixed error|0",
+			"WARN|-1||0|prefixed warn|0",
+		}},
+		{"continuation without record", "first\n  second\n2026/10/19 06:20:57 LOG:: next\n", nil, []string{
+			"NOFMT|-1||0|first|1",
+			"INFO|-1||0|next|0",
+		}},
+		{"stack start after NoFmt", "2026/10/19 06:20:57 raw\n2026/10/19 06:20:57 main.main()\n", nil, []string{
+			"NOFMT|-1||0|raw|0",
+			"NOFMT|-1||0|main.main()|0",
+		}},
+	}
+
+	for _, test := range tests {
+		format := test.format
+		if format != nil {
+			var err error
+			format, err = NewFormat(format)
+			if err != nil {
+				t.Fatalf("%v: %v", test.name, err)
+			}
+		}
+
+		got := summary(readAll(t, test.output, format))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReaderStackLines(t *testing.T) {
+	records := readAll(t, defaultOutput, nil)
+	r := records[4]
+	want := []string{"goroutine 1 [running]:", "runtime/debug.Stack()", "\t/usr/local/go/src/runtime/debug/stack.go:26 +0x5e", "main.main()", "\t/tmp/xl/gen/main.go:14 +0x16f"}
+	if !reflect.DeepEqual(r.Stack, want) {
+		t.Errorf("Stack = %q, want %q", r.Stack, want)
+	}
+	if len(r.Lines) != 6 || !strings.HasPrefix(r.Text(), "2026/10/19 06:20:57 ERROR!") {
+		t.Errorf("Lines = %q", r.Lines)
+	}
+}
+
+func TestNewFormatErrors(t *testing.T) {
+	formats := []*Format{
+		{Pattern: "%date %unknown"},
+		{Pattern: "%message %"},
+		{Pattern: "%date{iso"},
+		{Prefixes: map[string]string{"debug": "D>"}},
+	}
+	for _, format := range formats {
+		if _, err := NewFormat(format); err == nil {
+			t.Errorf("NewFormat(%+v) returned no error", format)
+		}
+	}
+}
+
+func TestReadFormat(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "xlogging.json")
+	writeFile(t, path, `{"levels":["info"],"pattern":"%date{iso} [%level] %message","prefixes":{"info":"I>"}}`, time.Now())
+
+	format, err := ReadFormat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := format.ParseLine("2026-10-19T06:20:59.474Z [WARN] from config", time.UTC)
+	if r == nil || r.Level != LevelWarn || r.Message != "from config" {
+		t.Errorf("ParseLine = %+v", r)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "xlogread")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func writeFile(t *testing.T, path, text string, modTime time.Time) {
+	err := ioutil.WriteFile(path, []byte(text), 0644)
+	if err == nil {
+		err = os.Chtimes(path, modTime, modTime)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := tempDir(t)
+	now := time.Now()
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"Log_17_10_2026.log", 3 * time.Hour},
+		{"Log_17_10_2026_1.log", 4 * time.Hour},
+		{"Log_18_10_2026.log.2", 2 * time.Hour},
+		{"Log_18_10_2026.log.gz", 2 * time.Hour},
+		{"Log_19_10_2026.log", time.Hour},
+		{"notes.txt", 0},
+		{"Log_19_10_2026.log.tmp", 0},
+		{".xlogging.lock", 0},
+	}
+	for _, file := range files {
+		writeFile(t, filepath.Join(dir, file.name), "", now.Add(-file.age))
+	}
+
+	got, err := ListFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		got[i] = filepath.Base(got[i])
+	}
+	want := []string{"Log_17_10_2026_1.log", "Log_17_10_2026.log", "Log_18_10_2026.log.2", "Log_18_10_2026.log.gz", "Log_19_10_2026.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListFiles = %q, want %q", got, want)
+	}
+
+	got, err = ListFiles(filepath.Join(dir, "notes.txt"))
+	if err != nil || len(got) != 1 {
+		t.Errorf("ListFiles(file) = %q, %v", got, err)
+	}
+}
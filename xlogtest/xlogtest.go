@@ -0,0 +1,231 @@
+//Package xlogtest captures xlogging output in memory so tests can check what was logged.
+//
+//	func TestFetch(t *testing.T) {
+//		xlogtest.New(t, xlogtest.WithTestLog())
+//		fetch()
+//		xlogtest.RequireLogged(t, xlogtest.Warn, "timeout")
+//	}
+//
+//xlogging settings are global, tests that capture can not run in parallel.
+package xlogtest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	xlog "github.com/jasdeepgrewal/xlogging"
+)
+
+//Levels of captured entries
+const (
+	Info  = xlog.LevelInfo
+	Warn  = xlog.LevelWarn
+	Error = xlog.LevelError
+	NoFmt = xlog.LevelNoFmt
+)
+
+//Logger holds the entries logged while it is installed
+type Logger struct {
+	t         testing.TB
+	toTestLog bool
+
+	mu      sync.Mutex
+	entries []xlog.Entry
+}
+
+//Option changes a Logger
+type Option func(l *Logger)
+
+//WithTestLog also writes every entry with t.Log so it shows with the test output
+func WithTestLog() Option {
+	return func(l *Logger) {
+		l.toTestLog = true
+	}
+}
+
+//current installed logger, used by the package level helpers
+var current *Logger
+var currentMu sync.Mutex
+
+//New installs a capture logger until the test ends. Nothing is written to the log file while it is installed
+func New(t testing.TB, opts ...Option) *Logger {
+	t.Helper()
+
+	l := &Logger{t: t}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	restore := xlog.Capture(l.add)
+
+	currentMu.Lock()
+	previous := current
+	current = l
+	currentMu.Unlock()
+
+	t.Cleanup(func() {
+		restore()
+		currentMu.Lock()
+		current = previous
+		currentMu.Unlock()
+	})
+
+	return l
+}
+
+func (l *Logger) add(e xlog.Entry) {
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	l.mu.Unlock()
+
+	if l.toTestLog {
+		l.t.Log(Format(e))
+	}
+}
+
+//Entries returns the captured entries
+func (l *Logger) Entries() []xlog.Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]xlog.Entry(nil), l.entries...)
+}
+
+//Reset removes the captured entries
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	l.entries = nil
+	l.mu.Unlock()
+}
+
+//Find returns the entries of level that contain substr in the message
+func (l *Logger) Find(level xlog.Level, substr string) []xlog.Entry {
+	var found []xlog.Entry
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+//Logged returns true if an entry of level contains substr in the message
+func (l *Logger) Logged(level xlog.Level, substr string) bool {
+	return len(l.Find(level, substr)) > 0
+}
+
+//RequireLogged stops the test if no entry of level contains substr
+func (l *Logger) RequireLogged(t testing.TB, level xlog.Level, substr string) {
+	t.Helper()
+	if !l.Logged(level, substr) {
+		t.Fatalf("xlogtest: no %v entry with %q. Logged:\n%v", LevelName(level), substr, l.dump())
+	}
+}
+
+//AssertLogged fails the test if no entry of level contains substr
+func (l *Logger) AssertLogged(t testing.TB, level xlog.Level, substr string) bool {
+	t.Helper()
+	if !l.Logged(level, substr) {
+		t.Errorf("xlogtest: no %v entry with %q. Logged:\n%v", LevelName(level), substr, l.dump())
+		return false
+	}
+	return true
+}
+
+//RequireNotLogged stops the test if an entry of level contains substr
+func (l *Logger) RequireNotLogged(t testing.TB, level xlog.Level, substr string) {
+	t.Helper()
+	if found := l.Find(level, substr); len(found) > 0 {
+		t.Fatalf("xlogtest: unexpected %v entry: %v", LevelName(level), Format(found[0]))
+	}
+}
+
+func (l *Logger) dump() string {
+	var lines []string
+	for _, e := range l.Entries() {
+		lines = append(lines, "\t"+Format(e))
+	}
+	if len(lines) == 0 {
+		return "\t<nothing>"
+	}
+	return strings.Join(lines, "\n")
+}
+
+//RequireLogged calls RequireLogged of the installed Logger
+func RequireLogged(t testing.TB, level xlog.Level, substr string) {
+	t.Helper()
+	getCurrent(t).RequireLogged(t, level, substr)
+}
+
+//AssertLogged calls AssertLogged of the installed Logger
+func AssertLogged(t testing.TB, level xlog.Level, substr string) bool {
+	t.Helper()
+	return getCurrent(t).AssertLogged(t, level, substr)
+}
+
+//RequireNotLogged calls RequireNotLogged of the installed Logger
+func RequireNotLogged(t testing.TB, level xlog.Level, substr string) {
+	t.Helper()
+	getCurrent(t).RequireNotLogged(t, level, substr)
+}
+
+func getCurrent(t testing.TB) *Logger {
+	t.Helper()
+
+	currentMu.Lock()
+	l := current
+	currentMu.Unlock()
+
+	if l == nil {
+		t.Fatal("xlogtest: no Logger installed, call xlogtest.New(t) first")
+	}
+	return l
+}
+
+//LevelName returns INFO, WARN, ERROR or NOFMT
+func LevelName(level xlog.Level) string {
+	switch level {
+	case Info:
+		return "INFO"
+	case Warn:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	case NoFmt:
+		return "NOFMT"
+	}
+	return "LEVEL(" + strconv.FormatUint(uint64(level), 10) + ")"
+}
+
+//Format returns an entry as one line: "[WARN] file.go(12) stream 3 | message key=value"
+func Format(e xlog.Entry) string {
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(LevelName(e.Level))
+	b.WriteString("]")
+	if e.File != "" {
+		b.WriteString(" ")
+		b.WriteString(filepath.Base(e.File))
+		b.WriteString("(")
+		b.WriteString(strconv.Itoa(e.Line))
+		b.WriteString(")")
+	}
+	if e.Stream >= 0 {
+		b.WriteString(" stream ")
+		b.WriteString(strconv.Itoa(e.Stream))
+		b.WriteString(" |")
+	}
+	b.WriteString(" ")
+	b.WriteString(e.Message)
+	for _, f := range e.Fields {
+		b.WriteString(" ")
+		b.WriteString(f.Key)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(fmt.Sprint(f.Value)))
+	}
+	return b.String()
+}
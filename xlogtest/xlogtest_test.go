@@ -0,0 +1,153 @@
+package xlogtest
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	xlog "github.com/jasdeepgrewal/xlogging"
+)
+
+//callerLine returns the line it is called from
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestNew(t *testing.T) {
+	l := New(t)
+	xlog.EnableStream(true, 3)
+
+	tests := []struct {
+		level   xlog.Level
+		stream  int
+		message string
+		log     func() int
+	}{
+		{Info, -1, "info entry", func() int {
+			xlog.Info("info entry")
+			return callerLine() - 1
+		}},
+		{Warn, -1, "warn entry", func() int {
+			xlog.Warn("warn entry")
+			return callerLine() - 1
+		}},
+		{Error, -1, "error entry", func() int {
+			xlog.Error("error entry")
+			return callerLine() - 1
+		}},
+		{Info, 3, "stream entry", func() int {
+			xlog.InfoS(3, "stream entry")
+			return callerLine() - 1
+		}},
+		{NoFmt, -1, "raw entry", func() int {
+			xlog.NoFmt("raw entry")
+			return callerLine() - 1
+		}},
+	}
+
+	for _, test := range tests {
+		l.Reset()
+		line := test.log()
+
+		entries := l.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("%q: captured %d entries, want 1", test.message, len(entries))
+		}
+		e := entries[0]
+		if e.Level != test.level || e.Stream != test.stream || e.Message != test.message {
+			t.Errorf("captured %v, want %v stream %d %q", Format(e), LevelName(test.level), test.stream, test.message)
+		}
+		if filepath.Base(e.File) != "xlogtest_test.go" || e.Line != line {
+			t.Errorf("%q: caller %v(%d), want xlogtest_test.go(%d)", test.message, e.File, e.Line, line)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	l := New(t)
+	xlog.Info("disk 80% full")
+	xlog.Warn("disk 95% full")
+	xlog.Warn("timeout")
+
+	tests := []struct {
+		level  xlog.Level
+		substr string
+		found  int
+	}{
+		{Info, "disk", 1},
+		{Warn, "disk", 1},
+		{Warn, "full", 1},
+		{Warn, "", 2},
+		{Error, "disk", 0},
+		{Info, "timeout", 0},
+		{Warn, "timeout", 1},
+	}
+	for _, test := range tests {
+		if found := l.Find(test.level, test.substr); len(found) != test.found {
+			t.Errorf("Find(%v, %q) = %d entries, want %d", LevelName(test.level), test.substr, len(found), test.found)
+		}
+		if logged := l.Logged(test.level, test.substr); logged != (test.found > 0) {
+			t.Errorf("Logged(%v, %q) = %v", LevelName(test.level), test.substr, logged)
+		}
+	}
+
+	RequireLogged(t, Warn, "timeout")
+	RequireNotLogged(t, Error, "timeout")
+	if !AssertLogged(t, Info, "80%") {
+		t.Error("AssertLogged returned false")
+	}
+}
+
+func TestRequireLoggedLevel(t *testing.T) {
+	l := New(t)
+	xlog.Info("timeout")
+
+	//A fake test records the failure instead of failing this test
+	fake := &fakeTB{TB: t}
+	l.AssertLogged(fake, Warn, "timeout")
+	if !fake.failed {
+		t.Error("AssertLogged matched an entry of another level")
+	}
+
+	fake = &fakeTB{TB: t}
+	l.AssertLogged(fake, Info, "timeout")
+	if fake.failed {
+		t.Error("AssertLogged did not match")
+	}
+}
+
+//fakeTB records Errorf instead of failing the test
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failed = true
+}
+
+func TestCleanupRestores(t *testing.T) {
+	outer := New(t)
+
+	t.Run("inner", func(t *testing.T) {
+		inner := New(t)
+		xlog.Warn("inner entry")
+		if !inner.Logged(Warn, "inner entry") || outer.Logged(Warn, "inner entry") {
+			t.Error("inner entry not captured by the inner Logger only")
+		}
+		if getCurrent(t) != inner {
+			t.Error("inner Logger is not installed")
+		}
+	})
+
+	xlog.Warn("outer entry")
+	if !outer.Logged(Warn, "outer entry") {
+		t.Error("previous Logger not restored")
+	}
+	if getCurrent(t) != outer {
+		t.Error("previous Logger is not the installed one")
+	}
+}